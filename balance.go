@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// checkBalance - verify that balance after each operation matches the previous balance and the operation amounts:
+// rest[i] == rest[i-1] + amount[i] - commission[i] + cashback[i]
+// records are from one statement file, so all balances are in the card currency,
+// mismatches are reported as warnings
+func checkBalance(filename string, data []monoparse.Record) int {
+	// monobank exports are sorted from newest to oldest, operations of the same second (purchase and its cashback)
+	// are in the same order, so the statement is reversed instead of sorting by time
+	sorted := make([]monoparse.Record, len(data))
+	for i, rec := range data {
+		sorted[len(data)-1-i] = rec
+	}
+
+	mismatches := 0
+	for i := 1; i < len(sorted); i++ {
+		prev, rec := sorted[i-1], sorted[i]
+		expected := prev.Rest + rec.Amount - rec.Commission + rec.Cashback
+		if expected != rec.Rest {
+			logger.Warnf("Balance mismatch in %s at %s %s: expected %s (%s after previous operation), got %s",
+				displayName(filename),
+				rec.CreatedAt.Format(monoparse.DateFormat), rec.Title,
+				formatDecimal(expected, monoparse.CentsCoef),
+				formatDecimal(prev.Rest, monoparse.CentsCoef),
+				formatDecimal(rec.Rest, monoparse.CentsCoef),
+			)
+			mismatches++
+		}
+	}
+
+	return mismatches
+}
+
+// filePair - two statement files, in order of import
+type filePair struct{ first, second string }
+
+// accountCheck - heuristic for statements of different accounts imported without -account:
+// the same operation in overlapping statements of one account has the same balance after it,
+// so the same time, title and amount with different balance means different accounts
+type accountCheck struct {
+	first      map[keyHash]accountRecord // first record by time, title and amount
+	pairs      []filePair
+	mismatches map[filePair]int
+}
+
+// accountRecord - fields of record for accountCheck, it's kept for each record of run
+type accountRecord struct {
+	file string
+	rest int
+}
+
+func newAccountCheck() *accountCheck {
+	return &accountCheck{first: map[keyHash]accountRecord{}, mismatches: map[filePair]int{}}
+}
+
+// add - check record against the first one with the same time, title and amount
+func (c *accountCheck) add(rec monoparse.Record) {
+	key := hashKey(rec.CreatedAt.Format(monoparse.DateFormat) + rec.Title + strconv.Itoa(rec.Amount))
+	prev, ok := c.first[key]
+	if !ok {
+		c.first[key] = accountRecord{file: rec.SourceFile, rest: rec.Rest}
+		return
+	}
+	if prev.file == rec.SourceFile || prev.rest == rec.Rest {
+		return
+	}
+
+	pair := filePair{prev.file, rec.SourceFile}
+	if c.mismatches[pair] == 0 {
+		c.pairs = append(c.pairs, pair)
+	}
+	c.mismatches[pair]++
+}
+
+// report - warning for each pair of files with mismatches, returns number of such operations
+func (c *accountCheck) report() int {
+	total := 0
+	for _, pair := range c.pairs {
+		logger.Warnf("%d operations in %s and %s have the same time, title and amount, but different balance, files may be from different accounts (use -account and separate imports)",
+			c.mismatches[pair], displayName(pair.first), displayName(pair.second))
+		total += c.mismatches[pair]
+	}
+
+	return total
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+func TestCheckBalance(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, time.February, 1, 10, minute, 0, 0, time.UTC) }
+	// newest first, as in monobank export, purchase and its cashback have the same time
+	data := []monoparse.Record{
+		{CreatedAt: at(5), Title: "Cashback", Amount: 500, Rest: 90500},
+		{CreatedAt: at(5), Title: "Shop", Amount: -10000, Rest: 90000},
+		{CreatedAt: at(1), Title: "Top up", Amount: 100000, Rest: 100000},
+	}
+
+	logs := captureLogs(t, func() {
+		if n := checkBalance("a.csv", data); n != 0 {
+			t.Errorf("expected no mismatches, got %d", n)
+		}
+	})
+	if logs != "" {
+		t.Errorf("unexpected messages: %s", logs)
+	}
+
+	// wrong balance after purchase
+	data[1].Rest = 89000
+	logs = captureLogs(t, func() {
+		if n := checkBalance("a.csv", data); n != 2 {
+			t.Errorf("expected 2 mismatches, got %d", n)
+		}
+	})
+	if !strings.Contains(logs, "Balance mismatch in a.csv at 01.02.2024 10:05:00 Shop") {
+		t.Errorf("expected mismatch of Shop, got: %s", logs)
+	}
+}
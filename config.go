@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// loadConfig - set flags from config file, flags given in command line are not changed,
+// file has TOML-like lines with flag names: `db = "mono.db"`, `tz = "UTC"`, `hash = true`, # comments
+func loadConfig(fs *flag.FlagSet, filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("Error opening config file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	explicit := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) {
+		explicit[fl.Name] = true
+	})
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("Error in config file %s, line %d: expected name = value", filename, lineNum)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("Error in config file %s, line %d: invalid string: %w", filename, lineNum, err)
+			}
+		} else if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1 {
+			// TOML literal string, without escapes
+			value = value[1 : len(value)-1]
+		}
+
+		if name == "config" || fs.Lookup(name) == nil {
+			return fmt.Errorf("Error in config file %s, line %d: unknown option %q", filename, lineNum, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("Error in config file %s, line %d: invalid value %q for %s: %w", filename, lineNum, value, name, err)
+		}
+	}
+
+	return scanner.Err()
+}
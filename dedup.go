@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// keyHash - hash of dedup key, keys of all records of run are kept in memory, so it's kept instead of
+// key string (about 50 bytes), 128 bits of SHA-256 make collisions improbable
+type keyHash [16]byte
+
+func hashKey(key string) keyHash {
+	sum := sha256.Sum256([]byte(key))
+	return keyHash(sum[:16])
+}
+
+// recordPos - position of the first record by dedup key: index of file in arguments and number of record
+type recordPos struct {
+	file, record int
+}
+
+// windowMatcher - matcher of duplicates for -dedup-window: records with the same key without time
+// (title, amount, ...) and operation times within window from the first such record are duplicates
+type windowMatcher struct {
+	window time.Duration
+	times  map[string][]time.Time // operation times of first records by key without time
+}
+
+func newWindowMatcher(window time.Duration) *windowMatcher {
+	return &windowMatcher{window: window, times: map[string][]time.Time{}}
+}
+
+// key - dedup key of record: key with operation time of the first record within window,
+// so duplicates get the same key, or with its own time for a new record
+func (m *windowMatcher) key(key string, createdAt time.Time) string {
+	for _, t := range m.times[key] {
+		if d := createdAt.Sub(t); d <= m.window && d >= -m.window {
+			return t.Format(monoparse.DateFormat) + key
+		}
+	}
+	m.times[key] = append(m.times[key], createdAt)
+
+	return createdAt.Format(monoparse.DateFormat) + key
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// exportRecord - record with human-readable amounts, for exporting to files
+type exportRecord struct {
+	CreatedAt  time.Time    `json:"created_at"`
+	Title      string       `json:"title"`
+	MCC        int          `json:"mcc"`
+	Amount     json.Number  `json:"amount"`
+	AmountOrig json.Number  `json:"amount_orig"`
+	Currency   string       `json:"currency"`
+	Exchange   *json.Number `json:"exchange"` // null for operations without conversion
+	Commission json.Number  `json:"commission"`
+	Cashback   json.Number  `json:"cashback"`
+	Rest       json.Number  `json:"rest"`
+	Category   string       `json:"category"`
+}
+
+// -export-units values
+const (
+	exportUnitsHryvnia = "hryvnia" // decimals with fixed 2 digits: -120.50
+	exportUnitsKopecks = "kopecks" // integers: -12050, exchange rates * 100000 as with -store-as=kopecks
+)
+
+// newExportRecord - amounts are decimals in UAH or integer kopecks with kopecks
+func newExportRecord(rec monoparse.Record, kopecks bool) exportRecord {
+	number := func(v, coef int) json.Number {
+		if kopecks {
+			return json.Number(strconv.Itoa(v))
+		}
+		return json.Number(formatDecimal(v, coef))
+	}
+
+	exp := exportRecord{
+		CreatedAt:  rec.CreatedAt,
+		Title:      rec.Title,
+		MCC:        rec.MCC,
+		Amount:     number(rec.Amount, monoparse.CentsCoef),
+		AmountOrig: number(rec.AmountOrig, monoparse.CentsCoef),
+		Currency:   rec.Currency,
+		Commission: number(rec.Commission, monoparse.CentsCoef),
+		Cashback:   number(rec.Cashback, monoparse.CentsCoef),
+		Rest:       number(rec.Rest, monoparse.CentsCoef),
+		Category:   rec.Category,
+	}
+	if rec.Exchange.Valid {
+		exchange := number(int(rec.Exchange.Int64), monoparse.RateCoef)
+		exp.Exchange = &exchange
+	}
+
+	return exp
+}
+
+// formatDecimal - format integer value as decimal without float rounding: (-12050, 100) -> "-120.50"
+func formatDecimal(v, coef int) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+
+	digits := len(strconv.Itoa(coef)) - 1
+	return fmt.Sprintf("%s%d.%0*d", sign, v/coef, digits, v%coef)
+}
+
+// exportToFile - write records to file or to stdout if filename is empty, units are -export-units value
+func exportToFile(filename, format, units string, records *recordSpool) error {
+	if filename == "" {
+		return export(os.Stdout, format, units, records)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %w", filename, err)
+	}
+
+	if err := export(f, format, units, records); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func export(w io.Writer, format, units string, records *recordSpool) error {
+	kopecks := units == exportUnitsKopecks
+	switch format {
+	case "json":
+		return exportJSON(w, records, kopecks)
+	case "csv":
+		return exportCSV(w, records, kopecks)
+	default:
+		return fmt.Errorf("Unsupported export format: %s", format)
+	}
+}
+
+// exportJSON - write records as JSON array one by one, the same output as of json.Encoder with indent for slice
+func exportJSON(w io.Writer, records *recordSpool, kopecks bool) error {
+	bw := bufio.NewWriter(w)
+	sep := "[\n  "
+	if err := records.Each(func(rec monoparse.Record) error {
+		data, err := json.MarshalIndent(newExportRecord(rec, kopecks), "  ", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = bw.WriteString(sep)
+		_, err = bw.Write(data)
+		sep = ",\n  "
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if records.Len() == 0 {
+		_, _ = bw.WriteString("[]\n")
+	} else {
+		_, _ = bw.WriteString("\n]\n")
+	}
+
+	return bw.Flush()
+}
+
+// exportCSV - write records as CSV with English header, same values as in JSON
+func exportCSV(w io.Writer, records *recordSpool, kopecks bool) error {
+	csvw := csv.NewWriter(w)
+	if err := csvw.Write([]string{
+		"created_at", "title", "mcc", "amount", "amount_orig", "currency",
+		"exchange", "commission", "cashback", "rest", "category",
+	}); err != nil {
+		return err
+	}
+
+	if err := records.Each(func(rec monoparse.Record) error {
+		exp := newExportRecord(rec, kopecks)
+		exchange := "" // empty for operations without conversion
+		if exp.Exchange != nil {
+			exchange = exp.Exchange.String()
+		}
+		return csvw.Write([]string{
+			exp.CreatedAt.Format(time.RFC3339),
+			exp.Title,
+			strconv.Itoa(exp.MCC),
+			exp.Amount.String(),
+			exp.AmountOrig.String(),
+			exp.Currency,
+			exchange,
+			exp.Commission.String(),
+			exp.Cashback.String(),
+			exp.Rest.String(),
+			exp.Category,
+		})
+	}); err != nil {
+		return err
+	}
+
+	csvw.Flush()
+	return csvw.Error()
+}
+
+// sortByTime - sort records by operation time, records with the same time keep their order
+func sortByTime(data []monoparse.Record) {
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].CreatedAt.Before(data[j].CreatedAt)
+	})
+}
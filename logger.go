@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+)
+
+type logLevel int
+
+const (
+	levelQuiet   logLevel = iota // only fatal errors
+	levelNormal                  // status messages and warnings
+	levelVerbose                 // also each record
+)
+
+// statusLogger - leveled logger for status messages,
+// fatal errors are reported by fatal/fatalf regardless of the level
+type statusLogger struct {
+	out      io.Writer // status messages
+	warnOut  io.Writer // warnings
+	level    logLevel
+	warnings []string     // all warnings, for -summary-format=json
+	slog     *slog.Logger // structured messages to stderr for -log-format, nil for plain messages
+}
+
+var logger = &statusLogger{out: os.Stdout, warnOut: os.Stderr, level: levelNormal}
+
+// setFormat - structured messages by log/slog: text (key=value) or json, all of them go to stderr
+func (l *statusLogger) setFormat(format string) error {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug} // level is checked by statusLogger
+	switch format {
+	case "":
+		l.slog = nil
+	case "text":
+		l.slog = slog.New(slog.NewTextHandler(os.Stderr, opts))
+	case "json":
+		l.slog = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		return fmt.Errorf("Unsupported log format: %s", format)
+	}
+
+	return nil
+}
+
+// Infof - status message
+func (l *statusLogger) Infof(format string, args ...any) {
+	l.With().Infof(format, args...)
+}
+
+// Warnf - non-fatal problem
+func (l *statusLogger) Warnf(format string, args ...any) {
+	l.With().Warnf(format, args...)
+}
+
+// Debugf - details for verbose mode
+func (l *statusLogger) Debugf(format string, args ...any) {
+	l.With().Debugf(format, args...)
+}
+
+// Errorf - fatal error, it's printed regardless of the level
+func (l *statusLogger) Errorf(format string, args ...any) {
+	if l.slog != nil {
+		l.slog.Error(fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// With - message with structured fields (key-value pairs as in slog), fields are printed only with -log-format
+func (l *statusLogger) With(fields ...any) logEntry {
+	return logEntry{l: l, fields: fields}
+}
+
+// logEntry - message with structured fields
+type logEntry struct {
+	l      *statusLogger
+	fields []any
+}
+
+func (e logEntry) Infof(format string, args ...any) {
+	if e.l.level >= levelNormal {
+		e.print(e.l.out, slog.LevelInfo, "", format, args...)
+	}
+}
+
+func (e logEntry) Warnf(format string, args ...any) {
+	e.l.warnings = append(e.l.warnings, fmt.Sprintf(format, args...))
+	if e.l.level >= levelNormal {
+		e.print(e.l.warnOut, slog.LevelWarn, "Warning: ", format, args...)
+	}
+}
+
+func (e logEntry) Debugf(format string, args ...any) {
+	if e.l.level >= levelVerbose {
+		e.print(e.l.out, slog.LevelDebug, "", format, args...)
+	}
+}
+
+func (e logEntry) print(out io.Writer, level slog.Level, prefix, format string, args ...any) {
+	if e.l.slog != nil {
+		e.l.slog.Log(context.Background(), level, fmt.Sprintf(format, args...), e.fields...)
+		return
+	}
+	fmt.Fprintf(out, prefix+format+"\n", args...)
+}
+
+// progress - counter of processed records, updated in place on one stderr line
+type progress struct {
+	out     io.Writer
+	total   int
+	step    int
+	printed bool
+}
+
+// newProgress - returns nil (no-op progress) if disabled, per-record output in verbose mode
+// and structured messages would be mixed with it
+func newProgress(enabled bool, total int) *progress {
+	if !enabled || logger.level != levelNormal || logger.slog != nil {
+		return nil
+	}
+
+	return &progress{out: os.Stderr, total: total, step: 1000}
+}
+
+// Update - n records are processed
+func (p *progress) Update(n int) {
+	if p == nil || (n%p.step != 0 && n != p.total) {
+		return
+	}
+
+	fmt.Fprintf(p.out, "\rprocessed %d/%d", n, p.total)
+	p.printed = true
+}
+
+// Done - finish progress line before other output
+func (p *progress) Done() {
+	if p == nil || !p.printed {
+		return
+	}
+
+	fmt.Fprintln(p.out)
+}
+
+// isTerminal - file is a character device (TTY), except /dev/null (e.g. stdin of cron jobs)
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(fi, null) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writeMetrics - write metrics of successful import in Prometheus text format for textfile collector
+// of node_exporter, the file is replaced by rename, so collector doesn't read a partially written file
+func writeMetrics(filename, table string, parsed int, results []targetResult, duration time.Duration, finished time.Time) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op after rename
+
+	printMetrics(f, table, parsed, results, duration, finished)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes file readable only by owner, collector may run as other user
+	if err := os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filename)
+}
+
+func printMetrics(w io.Writer, table string, parsed int, results []targetResult, duration time.Duration, finished time.Time) {
+	metric := func(name, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	tableLabel := "table=" + labelValue(table)
+
+	metric("mono_import_records_total", "Records read from statement files by the last import, after filters and dedup.")
+	fmt.Fprintf(w, "mono_import_records_total{%s} %d\n", tableLabel, parsed)
+
+	metric("mono_import_inserted_total", "Records inserted to DB by the last import.")
+	for _, res := range results {
+		fmt.Fprintf(w, "mono_import_inserted_total{db=%s,%s} %d\n", labelValue(redactDSN(res.opts.dsn)), tableLabel, insertedCount(res))
+	}
+
+	metric("mono_import_skipped_total", "Records skipped by the last import because they are already in DB.")
+	for _, res := range results {
+		fmt.Fprintf(w, "mono_import_skipped_total{db=%s,%s} %d\n", labelValue(redactDSN(res.opts.dsn)), tableLabel, parsed-insertedCount(res))
+	}
+
+	metric("mono_import_duration_seconds", "Duration of the last import, reading of files and saving to all DBs.")
+	fmt.Fprintf(w, "mono_import_duration_seconds{%s} %.3f\n", tableLabel, duration.Seconds())
+
+	metric("mono_import_last_success_timestamp", "Unix time of the last successful import.")
+	fmt.Fprintf(w, "mono_import_last_success_timestamp{%s} %d\n", tableLabel, finished.Unix())
+}
+
+// labelValue - quoted label value with escapes of text format
+func labelValue(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func insertedCount(res targetResult) int {
+	n := 0
+	for _, cnt := range res.inserted {
+		n += cnt
+	}
+
+	return n
+}
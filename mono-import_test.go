@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// readTestFile - records of file by readCSV, messages of parsing are dropped
+func readTestFile(t *testing.T, filename string, opts readOptions) []monoparse.Record {
+	t.Helper()
+
+	records := []monoparse.Record{}
+	rowErrs, err := readCSV(filename, opts, func(rec monoparse.Record) error {
+		records = append(records, rec)
+		return nil
+	}, func(func()) {})
+	if err != nil {
+		t.Fatalf("readCSV(%s): %s", filename, err)
+	}
+	if len(rowErrs) > 0 {
+		t.Fatalf("readCSV(%s): errors of records: %v", filename, rowErrs)
+	}
+
+	return records
+}
+
+func TestReadCSVWindows1251(t *testing.T) {
+	records := readTestFile(t, "testdata/cp1251.csv", readOptions{encoding: encodingWindows1251, failFast: true})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	for i, want := range []string{"АТБ-Маркет", "Від: Іван"} {
+		if records[i].Title != want {
+			t.Errorf("title of record %d: expected %q, got %q", i, want, records[i].Title)
+		}
+	}
+	// "—" placeholders are decoded too
+	if records[0].Commission != 0 || records[0].Exchange.Valid {
+		t.Errorf("unexpected values of placeholders: %+v", records[0])
+	}
+}
+
+func TestReadCSVGzip(t *testing.T) {
+	opts := readOptions{failFast: true}
+	want := readTestFile(t, "testdata/statement.csv", opts)
+	if len(want) == 0 {
+		t.Fatal("no records in plain file")
+	}
+
+	got := readTestFile(t, "testdata/statement.csv.gz", opts)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("records of .csv.gz differ from plain file:\n%+v\n%+v", got, want)
+	}
+
+	// gzip file without extension (e.g. stdin) is detected by magic header
+	data, err := os.ReadFile("testdata/statement.csv.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	noExt := filepath.Join(t.TempDir(), "statement.csv")
+	if err := os.WriteFile(noExt, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, noExt, opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("records of gzip file without .gz extension differ from plain file")
+	}
+}
+
+func TestReadCSVGzipExtensionOfPlainFile(t *testing.T) {
+	data, err := os.ReadFile("testdata/statement.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "statement.csv.gz")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readCSV(filename, readOptions{failFast: true}, func(monoparse.Record) error { return nil }, func(func()) {})
+	if err == nil || !strings.Contains(err.Error(), "gzip") {
+		t.Errorf("expected gzip error for plain file with .gz extension, got %v", err)
+	}
+}
+
+func TestReadFilesSourceFile(t *testing.T) {
+	abs, err := filepath.Abs("testdata/statement.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []monoparse.Record{}
+	captureLogs(t, func() {
+		// the same file by relative and absolute path, records of the second one are duplicates
+		duplicates, _, err := readFiles([]string{"./testdata/statement.csv", abs}, readOptions{parallel: 1, onDuplicate: onDuplicateSkip}, func(rec monoparse.Record) error {
+			records = append(records, rec)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(duplicates) != 1 || duplicates["statement.csv"].AcrossFiles != len(records) {
+			t.Errorf("expected duplicates of statement.csv, got %v", duplicates)
+		}
+	})
+
+	if len(records) == 0 {
+		t.Fatal("no records")
+	}
+	for _, rec := range records {
+		if rec.SourceFile != "statement.csv" {
+			t.Errorf("source file: expected statement.csv, got %q", rec.SourceFile)
+		}
+	}
+}
+
+func TestReadFilesPrintDuplicates(t *testing.T) {
+	logs := captureLogs(t, func() {
+		_, _, err := readFiles([]string{"testdata/statement.csv", "testdata/statement.csv"}, readOptions{parallel: 1, onDuplicate: onDuplicateSkip, printDupl: true}, func(monoparse.Record) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// the first record is listed by position in its file, duplicates follow it
+	records := readTestFile(t, "testdata/statement.csv", readOptions{})
+	for _, line := range []string{
+		fmt.Sprintf("Duplicates (%d operations):", len(records)),
+		"  testdata/statement.csv, record 0: 01.02.2024 10:00:00 АТБ -120.50",
+		"    duplicate: testdata/statement.csv, record 0: 01.02.2024 10:00:00 АТБ -120.50",
+	} {
+		if !strings.Contains(logs, line+"\n") {
+			t.Errorf("expected %q in messages:\n%s", line, logs)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package monodb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Computed - column computed on insert by arithmetic expression over fields of record, see ParseComputed
+type Computed struct {
+	Name string
+	sql  string // SQL with named parameters of record fields
+}
+
+// computedFields - fields of expressions: SQL of values in UAH and rates (as in decimal columns) regardless of Kopecks
+var computedFields = map[string]string{
+	"amount":      ":amount / 100.0",
+	"amount_orig": ":amount_orig / 100.0",
+	"exchange":    ":exchange / 100000.0",
+	"commission":  ":commission / 100.0",
+	"cashback":    ":cashback / 100.0",
+	"rest":        ":rest / 100.0",
+	"mcc":         ":mcc",
+}
+
+// ParseComputed - parse "name=expr" spec of computed column, expression has numbers, fields (amount, amount_orig,
+// exchange, commission, cashback, rest, mcc), + - * / and parentheses, e.g. "amount_eur=amount / 41.5",
+// result is NULL for NULL exchange and for division by zero
+func ParseComputed(spec string) (Computed, error) {
+	name, expr, ok := strings.Cut(spec, "=")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || expr == "" {
+		return Computed{}, fmt.Errorf("Invalid computed column %q, expected name=expression", spec)
+	}
+	if !tableNameRe.MatchString(name) {
+		return Computed{}, fmt.Errorf("Invalid computed column name: %q", name)
+	}
+
+	tokens, err := exprTokens(expr)
+	if err != nil {
+		return Computed{}, fmt.Errorf("Error parsing expression of column %s: %w", name, err)
+	}
+	p := exprParser{tokens: tokens}
+	sql, err := p.expr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	if err != nil {
+		return Computed{}, fmt.Errorf("Error parsing expression of column %s: %w", name, err)
+	}
+
+	return Computed{Name: strings.ToLower(name), sql: sql}, nil
+}
+
+// numberRe - numbers of expressions are decimals, without exponent
+var numberRe = regexp.MustCompile(`^(\d+(\.\d*)?|\.\d+)$`)
+
+// exprTokens - numbers, names and one-character operators
+func exprTokens(expr string) ([]string, error) {
+	tokens := []string{}
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.ContainsRune("+-*/()", c):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '.' || unicode.IsDigit(c) || c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(expr) && (expr[j] == '.' || expr[j] == '_' || unicode.IsDigit(rune(expr[j])) || unicode.IsLetter(rune(expr[j]))) {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+
+	return tokens, nil
+}
+
+// exprParser - recursive descent parser of expression to fully parenthesized SQL:
+//
+//	expr  = term {("+" | "-") term}
+//	term  = unary {("*" | "/") unary}
+//	unary = "-" unary | number | field | "(" expr ")"
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) next() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+func (p *exprParser) expr() (string, error) {
+	left, err := p.term()
+	if err != nil {
+		return "", err
+	}
+	for op := p.next(); op == "+" || op == "-"; op = p.next() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " " + op + " " + right + ")"
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) term() (string, error) {
+	left, err := p.unary()
+	if err != nil {
+		return "", err
+	}
+	for op := p.next(); op == "*" || op == "/"; op = p.next() {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+		if op == "/" {
+			// NULL instead of error of PostgreSQL
+			right = "NULLIF(" + right + ", 0)"
+		}
+		left = "(" + left + " " + op + " " + right + ")"
+	}
+
+	return left, nil
+}
+
+func (p *exprParser) unary() (string, error) {
+	token := p.next()
+	p.pos++
+	switch {
+	case token == "":
+		return "", fmt.Errorf("unexpected end of expression")
+	case strings.Contains("+*/)", token):
+		return "", fmt.Errorf("unexpected %q", token)
+	case token == "-":
+		operand, err := p.unary()
+		if err != nil {
+			return "", err
+		}
+		return "(-" + operand + ")", nil
+	case token == "(":
+		sql, err := p.expr()
+		if err != nil {
+			return "", err
+		}
+		if p.next() != ")" {
+			return "", fmt.Errorf("missing )")
+		}
+		p.pos++
+		return sql, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil || !numberRe.MatchString(token) {
+			return "", fmt.Errorf("invalid number %q", token)
+		}
+		// decimal point for float division of integers (mcc) in SQLite and PostgreSQL
+		num := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(num, ".") {
+			num += ".0"
+		}
+		return num, nil
+	default:
+		sql, ok := computedFields[strings.ToLower(token)]
+		if !ok {
+			return "", fmt.Errorf("unknown field %q", token)
+		}
+		return "(" + sql + ")", nil
+	}
+}
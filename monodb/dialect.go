@@ -0,0 +1,585 @@
+package monodb
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// Dialect - SQL differences between supported DB drivers
+type Dialect interface {
+	CreateTableSQL() string
+	InsertSQL() string
+	ColumnsSQL() string              // query for names of existing table columns
+	AddColumnSQL(col Column) string  // for migration of tables created by older versions
+	IndexNamesSQL() string           // query for names of existing table indexes
+	UniqueIndexesSQL() string        // query for index name and column name of unique indexes, ordered by index
+	CreateIndexSQL(idx Index) string // indexes are created after table and migration of columns
+	CreateMetaTableSQL() string      // table of import runs, see MetaTable
+	Indexes() []Index
+	CountSQL() string
+	Columns() []Column
+	MonthSQL() string // expression of created_at month: 2006-01
+	WeekSQL() string  // expression of created_at week, weeks start on Monday: 2006-W01
+}
+
+// Options - table name, optional columns of the table and import settings
+type Options struct {
+	Table       string
+	KeepRaw     bool     // raw_amount column with original amount string from CSV
+	Account     bool     // account column, part of unique key
+	DedupKey    DedupKey // unique key of records, DedupDateTitleAmount if empty
+	SplitAmount bool     // debit/credit columns, non-negative amounts by sign of amount
+	KeepSource  bool     // source_file column with CSV file name
+	Kopecks     bool     // amounts as INTEGER of kopecks (cents) and rates * 100000, without conversion to decimals
+	Minimal     bool     // only created_at, title, mcc, amount, rest (and optional) columns, for UAH-only statements
+	Replace     bool     // update existing records on conflict instead of skipping them
+	FastLoad    bool     // plain INSERT without conflict handling, for empty table only, see Import
+	RecordRun   bool     // record each import (time, Version, files, counts) in MetaTable
+	Version     string   // version of the program for MetaTable
+	// MarkTransfers - transfer column, pairs of records of transfers between accounts are marked after import,
+	// needs Account, see markTransfers
+	MarkTransfers bool
+	Computed      []Computed // columns computed on insert by expressions, see ParseComputed
+
+	// Progress - called after each inserted batch with number of processed records
+	Progress func(done int)
+	// Record - called for each record, inserted is false for records already in DB, by RowsAffected of INSERT,
+	// so with Replace updated records may be reported as inserted (they are not counted by Import),
+	// records are inserted one by one if it's set
+	Record func(rec monoparse.Record, inserted bool)
+	// Logf - status messages about migration of the table and marked transfers
+	Logf func(format string, args ...any)
+	// Warnf - problems of the table which don't stop import, e.g. unique key which doesn't match DedupKey
+	Warnf func(format string, args ...any)
+}
+
+// DedupKey - uniqueness strategy: columns of UNIQUE constraint and ON CONFLICT target
+type DedupKey string
+
+const (
+	DedupDateTitleAmount     DedupKey = "date+title+amount"      // UNIQUE (created_at, title, amount), default
+	DedupDateTitleAmountRest DedupKey = "date+title+amount+rest" // also balance, for distinct operations with the same time, title and amount
+	DedupFullHash            DedupKey = "full-hash"              // hash column (SHA-256 of all fields) with unique index
+	DedupNone                DedupKey = "none"                   // without unique key, all records are inserted
+)
+
+// ParseDedupKey - check name of dedup key
+func ParseDedupKey(name string) (DedupKey, error) {
+	switch key := DedupKey(name); key {
+	case DedupDateTitleAmount, DedupDateTitleAmountRest, DedupFullHash, DedupNone:
+		return key, nil
+	default:
+		return "", fmt.Errorf("Unsupported dedup key: %s", name)
+	}
+}
+
+// tableNameRe - table name is interpolated into SQL, so only simple identifiers are allowed
+var tableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// NewDialect - dialect of DB driver: sqlite3, postgres, mysql
+func NewDialect(driver string, opts Options) (Dialect, error) {
+	if !tableNameRe.MatchString(opts.Table) {
+		return nil, fmt.Errorf("Invalid table name: %q", opts.Table)
+	}
+
+	names := map[string]bool{}
+	for _, col := range tableColumns(opts) {
+		if names[col.name] {
+			return nil, fmt.Errorf("Duplicate column %s, computed columns need other names", col.name)
+		}
+		names[col.name] = true
+	}
+
+	switch driver {
+	case "sqlite3":
+		return sqliteDialect{opts: opts}, nil
+	case "postgres":
+		return postgresDialect{opts: opts}, nil
+	case "mysql":
+		return mysqlDialect{opts: opts}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported DB driver: %s", driver)
+	}
+}
+
+type columnType int
+
+const (
+	typeDateTime columnType = iota
+	typeText
+	typeLongText // not indexed text, e.g. list of files, TEXT in MySQL too
+	typeInteger
+	typeMoney // UAH, value * 100 in record
+	typeRate  // exchange rate, value * 100000 in record
+	typeBoolean
+	typeFloat // computed values
+)
+
+// Column - column of the table
+type Column struct {
+	name    string
+	colType columnType
+	value   string // named parameter expression for insert
+}
+
+func tableColumns(opts Options) []Column {
+	// money/rate column: decimal value or integer as is in record
+	decimal := func(name string, colType columnType, coef string) Column {
+		if opts.Kopecks {
+			return Column{name, typeInteger, ":" + name}
+		}
+		return Column{name, colType, ":" + name + " / " + coef}
+	}
+
+	columns := []Column{
+		{"created_at", typeDateTime, ":created_at"},
+		{"title", typeText, ":title"},
+		{"mcc", typeInteger, ":mcc"},
+		decimal("amount", typeMoney, "100.0"),
+		decimal("amount_orig", typeMoney, "100.0"),
+		{"currency", typeText, ":currency"},
+		decimal("exchange", typeRate, "100000.0"),
+		decimal("commission", typeMoney, "100.0"),
+		decimal("cashback", typeMoney, "100.0"),
+		decimal("rest", typeMoney, "100.0"),
+		{"category", typeText, ":category"},
+	}
+	if opts.Minimal {
+		columns = slices.DeleteFunc(columns, func(col Column) bool { return !minimalColumns[col.name] })
+	}
+
+	if opts.KeepRaw {
+		columns = append(columns, Column{"raw_amount", typeText, ":raw_amount"})
+	}
+	if opts.Account {
+		columns = append(columns, Column{"account", typeText, ":account"})
+	}
+	if opts.DedupKey == DedupFullHash {
+		columns = append(columns, Column{"hash", typeText, ":hash"})
+	}
+	if opts.KeepSource {
+		columns = append(columns, Column{"source_file", typeText, ":source_file"})
+	}
+	if opts.MarkTransfers {
+		// records are marked after import, see markTransfers
+		columns = append(columns, Column{"transfer", typeBoolean, "FALSE"})
+	}
+	for _, comp := range opts.Computed {
+		columns = append(columns, Column{comp.Name, typeFloat, comp.sql})
+	}
+	if opts.SplitAmount {
+		// monobank amount is negative for outgoing operations
+		if opts.Kopecks {
+			columns = append(columns,
+				Column{"debit", typeInteger, "CASE WHEN :amount < 0 THEN -:amount ELSE 0 END"},
+				Column{"credit", typeInteger, "CASE WHEN :amount > 0 THEN :amount ELSE 0 END"},
+			)
+		} else {
+			columns = append(columns,
+				Column{"debit", typeMoney, "CASE WHEN :amount < 0 THEN :amount / -100.0 ELSE 0 END"},
+				Column{"credit", typeMoney, "CASE WHEN :amount > 0 THEN :amount / 100.0 ELSE 0 END"},
+			)
+		}
+	}
+
+	return columns
+}
+
+// minimalColumns - columns of Options.Minimal table besides optional ones
+var minimalColumns = map[string]bool{"created_at": true, "title": true, "mcc": true, "amount": true, "rest": true}
+
+// uniqueColumns - columns for UNIQUE constraint and ON CONFLICT target, empty for DedupNone
+func uniqueColumns(opts Options) string {
+	switch opts.DedupKey {
+	case DedupFullHash:
+		return "hash"
+	case DedupNone:
+		return ""
+	}
+
+	columns := "created_at, title, amount"
+	if opts.DedupKey == DedupDateTitleAmountRest {
+		columns += ", rest"
+	}
+	if opts.Account {
+		columns += ", account"
+	}
+
+	return columns
+}
+
+func createTableSQL(opts Options, typeName func(columnType) string) string {
+	lines := []string{}
+	for _, col := range tableColumns(opts) {
+		lines = append(lines, fmt.Sprintf("\t\t%-11s %s", col.name, typeName(col.colType)))
+	}
+	// unique index for hash is created separately, so it can be added to existing tables
+	if opts.DedupKey != DedupFullHash && opts.DedupKey != DedupNone {
+		lines = append(lines, "\n\t\tUNIQUE ("+uniqueColumns(opts)+")")
+	}
+
+	return "\n\tCREATE TABLE IF NOT EXISTS " + opts.Table + " (\n" + strings.Join(lines, ",\n") + "\n\t)"
+}
+
+// MetaTable - table of import runs, shared by all tables of DB
+const MetaTable = "import_meta"
+
+// ImportRun - one import run, row of MetaTable
+type ImportRun struct {
+	ImportedAt time.Time `db:"imported_at"`
+	Version    string    `db:"version"`
+	TableName  string    `db:"table_name"`
+	Files      string    `db:"files"` // source files of records, comma-separated
+	Parsed     int       `db:"parsed"`
+	Inserted   int       `db:"inserted"`
+}
+
+func createMetaTableSQL(typeName func(columnType) string) string {
+	return fmt.Sprintf(`
+	CREATE TABLE IF NOT EXISTS %s (
+		imported_at %s,
+		version     %s,
+		table_name  %s,
+		files       %s,
+		parsed      %s,
+		inserted    %s
+	)`, MetaTable, typeName(typeDateTime), typeName(typeText), typeName(typeText), typeName(typeLongText), typeName(typeInteger), typeName(typeInteger))
+}
+
+// insertMetaSQL - named parameters are rebound by sqlx as in insertSQL
+const insertMetaSQL = "INSERT INTO " + MetaTable + " (imported_at, version, table_name, files, parsed, inserted) VALUES (:imported_at, :version, :table_name, :files, :parsed, :inserted)"
+
+// Index - index of the table
+type Index struct {
+	name    string
+	columns string
+	unique  bool
+}
+
+// tableIndexes - indexes for reports by date and category, unique index for hash
+func tableIndexes(opts Options) []Index {
+	indexes := []Index{
+		{opts.Table + "_created_at_idx", "created_at", false},
+		{opts.Table + "_mcc_idx", "mcc", false},
+	}
+	if !opts.Minimal {
+		indexes = append(indexes, Index{opts.Table + "_category_idx", "category", false})
+	}
+	if opts.DedupKey == DedupFullHash {
+		indexes = append(indexes, Index{opts.Table + "_hash_idx", "hash", true})
+	}
+
+	return indexes
+}
+
+func createIndexSQL(opts Options, idx Index) string {
+	unique := ""
+	if idx.unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)", unique, idx.name, opts.Table, idx.columns)
+}
+
+func addColumnSQL(opts Options, col Column, typeName func(columnType) string) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", opts.Table, col.name, typeName(col.colType))
+}
+
+// onConflictSQL - skip records which are already in DB or update them with -on-conflict=replace
+func onConflictSQL(opts Options) string {
+	if opts.DedupKey == DedupNone || opts.FastLoad {
+		return ""
+	}
+	if !opts.Replace {
+		return "ON CONFLICT(" + uniqueColumns(opts) + ") DO NOTHING"
+	}
+
+	return "ON CONFLICT(" + uniqueColumns(opts) + ") DO UPDATE SET\n\t\t" + strings.Join(updateColumns(opts, "%[1]s = excluded.%[1]s"), ",\n\t\t")
+}
+
+// updateColumns - SET expressions by format for all columns except unique key
+func updateColumns(opts Options, format string) []string {
+	key := map[string]bool{}
+	for _, name := range strings.Split(uniqueColumns(opts), ", ") {
+		key[name] = true
+	}
+
+	sets := []string{}
+	for _, col := range tableColumns(opts) {
+		if !key[col.name] {
+			sets = append(sets, fmt.Sprintf(format, col.name))
+		}
+	}
+
+	return sets
+}
+
+// insertSQL - named parameters are rebound by sqlx to the driver bindvar style (? or $N)
+func insertSQL(opts Options, onConflict string) string {
+	names, values := []string{}, []string{}
+	for _, col := range tableColumns(opts) {
+		names = append(names, col.name)
+		values = append(values, col.value)
+	}
+
+	return fmt.Sprintf(`
+	INSERT INTO %s (
+		%s
+	) VALUES (
+		%s
+	)
+	%s
+`, opts.Table, strings.Join(names, ",\n\t\t"), strings.Join(values, ",\n\t\t"), onConflict)
+}
+
+type sqliteDialect struct {
+	opts Options
+}
+
+func (d sqliteDialect) typeName(t columnType) string {
+	switch t {
+	case typeDateTime:
+		return "DATETIME"
+	case typeInteger:
+		return "INTEGER"
+	case typeMoney:
+		return "DECIMAL(10,2)"
+	case typeRate:
+		return "DECIMAL(10,5)"
+	case typeBoolean:
+		return "BOOLEAN"
+	case typeFloat:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+func (d sqliteDialect) CreateMetaTableSQL() string {
+	return createMetaTableSQL(d.typeName)
+}
+
+func (d sqliteDialect) CreateTableSQL() string {
+	return createTableSQL(d.opts, d.typeName)
+}
+
+func (d sqliteDialect) InsertSQL() string {
+	return insertSQL(d.opts, onConflictSQL(d.opts))
+}
+
+func (d sqliteDialect) ColumnsSQL() string {
+	return "SELECT name FROM pragma_table_info('" + d.opts.Table + "')"
+}
+
+func (d sqliteDialect) AddColumnSQL(col Column) string {
+	return addColumnSQL(d.opts, col, d.typeName)
+}
+
+func (d sqliteDialect) IndexNamesSQL() string {
+	return "SELECT name FROM pragma_index_list('" + d.opts.Table + "')"
+}
+
+func (d sqliteDialect) UniqueIndexesSQL() string {
+	return "SELECT il.name AS index_name, ii.name AS column_name FROM pragma_index_list('" + d.opts.Table + "') AS il, pragma_index_info(il.name) AS ii WHERE il.\"unique\" = 1 ORDER BY il.name, ii.seqno"
+}
+
+func (d sqliteDialect) CreateIndexSQL(idx Index) string {
+	return createIndexSQL(d.opts, idx)
+}
+
+func (d sqliteDialect) Indexes() []Index {
+	return tableIndexes(d.opts)
+}
+
+func (d sqliteDialect) CountSQL() string {
+	return "SELECT COUNT(*) FROM " + d.opts.Table
+}
+
+func (d sqliteDialect) Columns() []Column {
+	return tableColumns(d.opts)
+}
+
+// MonthSQL - times are stored as text with original time zone,
+// date functions would convert them to UTC, so local date is cut from text
+func (d sqliteDialect) MonthSQL() string {
+	return "substr(created_at, 1, 7)"
+}
+
+func (d sqliteDialect) WeekSQL() string {
+	return "strftime('%Y-W%W', substr(created_at, 1, 10))"
+}
+
+type postgresDialect struct {
+	opts Options
+}
+
+func (d postgresDialect) typeName(t columnType) string {
+	switch t {
+	case typeDateTime:
+		return "TIMESTAMP"
+	case typeInteger:
+		return "INTEGER"
+	case typeMoney:
+		return "NUMERIC(10,2)"
+	case typeRate:
+		return "NUMERIC(10,5)"
+	case typeBoolean:
+		return "BOOLEAN"
+	case typeFloat:
+		return "DOUBLE PRECISION"
+	default:
+		return "TEXT"
+	}
+}
+
+func (d postgresDialect) CreateMetaTableSQL() string {
+	return createMetaTableSQL(d.typeName)
+}
+
+func (d postgresDialect) CreateTableSQL() string {
+	return createTableSQL(d.opts, d.typeName)
+}
+
+func (d postgresDialect) InsertSQL() string {
+	return insertSQL(d.opts, onConflictSQL(d.opts))
+}
+
+func (d postgresDialect) ColumnsSQL() string {
+	return "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = '" + d.opts.Table + "'"
+}
+
+func (d postgresDialect) AddColumnSQL(col Column) string {
+	return addColumnSQL(d.opts, col, d.typeName)
+}
+
+func (d postgresDialect) IndexNamesSQL() string {
+	return "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = '" + d.opts.Table + "'"
+}
+
+func (d postgresDialect) UniqueIndexesSQL() string {
+	return `SELECT i.relname AS index_name, a.attname AS column_name FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(x.indkey)
+		WHERE x.indisunique AND t.relnamespace = current_schema()::regnamespace AND t.relname = '` + d.opts.Table + `'
+		ORDER BY i.relname, array_position(x.indkey::int2[], a.attnum)`
+}
+
+func (d postgresDialect) CreateIndexSQL(idx Index) string {
+	return createIndexSQL(d.opts, idx)
+}
+
+func (d postgresDialect) Indexes() []Index {
+	return tableIndexes(d.opts)
+}
+
+func (d postgresDialect) CountSQL() string {
+	return "SELECT COUNT(*) FROM " + d.opts.Table
+}
+
+func (d postgresDialect) Columns() []Column {
+	return tableColumns(d.opts)
+}
+
+func (d postgresDialect) MonthSQL() string {
+	return "to_char(created_at, 'YYYY-MM')"
+}
+
+// WeekSQL - ISO week, may differ from SQLite week number for first days of year
+func (d postgresDialect) WeekSQL() string {
+	return `to_char(created_at, 'IYYY-"W"IW')`
+}
+
+type mysqlDialect struct {
+	opts Options
+}
+
+// typeName - TEXT columns can't be in unique key without prefix length, so VARCHAR is used
+func (d mysqlDialect) typeName(t columnType) string {
+	switch t {
+	case typeDateTime:
+		return "DATETIME"
+	case typeInteger:
+		return "INTEGER"
+	case typeMoney:
+		return "DECIMAL(10,2)"
+	case typeRate:
+		return "DECIMAL(10,5)"
+	case typeBoolean:
+		return "BOOLEAN"
+	case typeFloat:
+		return "DOUBLE"
+	case typeLongText:
+		return "TEXT"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+func (d mysqlDialect) CreateMetaTableSQL() string {
+	return createMetaTableSQL(d.typeName)
+}
+
+func (d mysqlDialect) CreateTableSQL() string {
+	return createTableSQL(d.opts, d.typeName)
+}
+
+// InsertSQL - no-op update for duplicates, INSERT IGNORE would also ignore other errors (e.g. too long values)
+func (d mysqlDialect) InsertSQL() string {
+	if d.opts.DedupKey == DedupNone || d.opts.FastLoad {
+		return insertSQL(d.opts, "")
+	}
+	if d.opts.Replace {
+		return insertSQL(d.opts, "ON DUPLICATE KEY UPDATE\n\t\t"+strings.Join(updateColumns(d.opts, "%[1]s = VALUES(%[1]s)"), ",\n\t\t"))
+	}
+
+	return insertSQL(d.opts, "ON DUPLICATE KEY UPDATE created_at = created_at")
+}
+
+func (d mysqlDialect) ColumnsSQL() string {
+	return "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = '" + d.opts.Table + "'"
+}
+
+func (d mysqlDialect) AddColumnSQL(col Column) string {
+	return addColumnSQL(d.opts, col, d.typeName)
+}
+
+func (d mysqlDialect) IndexNamesSQL() string {
+	return "SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = '" + d.opts.Table + "'"
+}
+
+func (d mysqlDialect) UniqueIndexesSQL() string {
+	return "SELECT index_name AS index_name, column_name AS column_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = '" + d.opts.Table + "' AND non_unique = 0 ORDER BY index_name, seq_in_index"
+}
+
+// CreateIndexSQL - "CREATE INDEX IF NOT EXISTS" is supported by MariaDB, but not by MySQL,
+// only missing indexes are created anyway
+func (d mysqlDialect) CreateIndexSQL(idx Index) string {
+	return strings.Replace(createIndexSQL(d.opts, idx), " IF NOT EXISTS", "", 1)
+}
+
+func (d mysqlDialect) Indexes() []Index {
+	return tableIndexes(d.opts)
+}
+
+func (d mysqlDialect) CountSQL() string {
+	return "SELECT COUNT(*) FROM " + d.opts.Table
+}
+
+func (d mysqlDialect) Columns() []Column {
+	return tableColumns(d.opts)
+}
+
+func (d mysqlDialect) MonthSQL() string {
+	return "DATE_FORMAT(created_at, '%Y-%m')"
+}
+
+// WeekSQL - ISO week, as in PostgreSQL
+func (d mysqlDialect) WeekSQL() string {
+	return "DATE_FORMAT(created_at, '%x-W%v')"
+}
@@ -0,0 +1,409 @@
+/*
+Package monodb - saving records of monobank statements to SQL DB (SQLite, PostgreSQL, MySQL/MariaDB)
+
+	db, err := sqlx.Open("sqlite3", "mono.db")
+	...
+	inserted, err := monodb.Import(db, records, monodb.Options{Table: "mono"})
+*/
+package monodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/msoap/mono-import/monoparse"
+)
+
+const batchSize = 100 // records in one multi-row INSERT, longer statements are slower to prepare in SQLite
+
+// Import - create or migrate the table and save records to it in one transaction,
+// records which are already in DB are skipped (or updated with Options.Replace),
+// returns number of inserted records. With Options.FastLoad the table must be empty and records
+// must be unique, they are inserted without conflict handling and counting of rows, it's not for re-imports
+func Import(db *sqlx.DB, recs []monoparse.Record, opts Options) (int, error) {
+	inserted, err := ImportContext(context.Background(), db, recs, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, cnt := range inserted {
+		n += cnt
+	}
+
+	return n, nil
+}
+
+// ImportContext - same as Import, returns number of inserted records per source file (Record.SourceFile),
+// on cancel of ctx the transaction is rolled back and nothing is saved,
+// errors wrap driver errors, creating of table and migrations are idempotent, so import can be retried
+func ImportContext(ctx context.Context, db *sqlx.DB, recs []monoparse.Record, opts Options) (map[string]int, error) {
+	return ImportFrom(ctx, db, Records(recs), opts)
+}
+
+// Source - records for import, fn is called for each record in order, reading stops on error of fn,
+// the source is read again on each import, e.g. on retry
+type Source func(fn func(monoparse.Record) error) error
+
+// Records - source of records of slice
+func Records(recs []monoparse.Record) Source {
+	return func(fn func(monoparse.Record) error) error {
+		for _, rec := range recs {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ImportFrom - same as ImportContext, records are read from src by batches while inserting,
+// so they don't need to be in memory, e.g. for records spooled to a temporary file
+func ImportFrom(ctx context.Context, db *sqlx.DB, src Source, opts Options) (map[string]int, error) {
+	dl, err := NewDialect(db.DriverName(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// before any changes of the table, its unique key can't be changed by migration
+	if err := checkUniqueKeyMismatch(ctx, db, dl, opts); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, dl.CreateTableSQL()); err != nil {
+		return nil, fmt.Errorf("Error creating table: %w", err)
+	}
+	if opts.RecordRun {
+		if _, err := db.ExecContext(ctx, dl.CreateMetaTableSQL()); err != nil {
+			return nil, fmt.Errorf("Error creating table %s: %w", MetaTable, err)
+		}
+	}
+
+	if err := migrateTable(ctx, db, dl, opts); err != nil {
+		return nil, err
+	}
+
+	// with fast load indexes are created after inserting, in the same transaction
+	if !opts.FastLoad {
+		if err := migrateIndexes(ctx, db, dl); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkUniqueKey(ctx, db, dl, opts); err != nil {
+		return nil, err
+	}
+
+	return insertRecords(ctx, db, dl, opts, src)
+}
+
+// migrateTable - add columns which are missing in the table created by older version,
+// is idempotent, so runs before each import
+func migrateTable(ctx context.Context, db *sqlx.DB, dl Dialect, opts Options) error {
+	exists, err := tableColumnNames(ctx, db, dl)
+	if err != nil {
+		return err
+	}
+
+	for _, col := range dl.Columns() {
+		if exists[col.name] {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, dl.AddColumnSQL(col)); err != nil {
+			return fmt.Errorf("Error adding column %s: %w", col.name, err)
+		}
+		if opts.Logf != nil {
+			opts.Logf("Added column %s to the table", col.name)
+		}
+	}
+
+	return checkColumns(ctx, db, dl, opts)
+}
+
+// checkColumns - check that the table has all columns after migration, instead of "no such column" errors
+// of inserting, e.g. for table with the same name in other schema or created by an incompatible version
+func checkColumns(ctx context.Context, db *sqlx.DB, dl Dialect, opts Options) error {
+	exists, err := tableColumnNames(ctx, db, dl)
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, col := range dl.Columns() {
+		if !exists[col.name] {
+			missing = append(missing, col.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Table %s has no columns %s after migration, it's incompatible and needs to be rebuilt or imported to other table", opts.Table, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// migrateIndexes - create indexes which are missing in the table, db is DB or transaction
+func migrateIndexes(ctx context.Context, db sqlx.ExtContext, dl Dialect) error {
+	existing := []string{}
+	if err := sqlx.SelectContext(ctx, db, &existing, dl.IndexNamesSQL()); err != nil {
+		return fmt.Errorf("Error getting table indexes: %w", err)
+	}
+
+	exists := map[string]bool{}
+	for _, name := range existing {
+		exists[strings.ToLower(name)] = true
+	}
+
+	for _, idx := range dl.Indexes() {
+		if exists[strings.ToLower(idx.name)] {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, dl.CreateIndexSQL(idx)); err != nil {
+			return fmt.Errorf("Error creating index %s: %w", idx.name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkUniqueKeyMismatch - error for existing table with unique key which doesn't match DedupKey,
+// e.g. the table was created with other dedup key: records which are new by DedupKey would fail
+// on the unique key of the table, or ON CONFLICT wouldn't match any unique key
+func checkUniqueKeyMismatch(ctx context.Context, db *sqlx.DB, dl Dialect, opts Options) error {
+	indexes, err := uniqueIndexes(ctx, db, dl)
+	if err != nil {
+		return err
+	}
+	for _, columns := range indexes {
+		if columns != uniqueColumns(opts) {
+			return errors.New(uniqueKeyMismatch(opts, columns))
+		}
+	}
+
+	return nil
+}
+
+// checkUniqueKey - warn if unique indexes of the table don't match DedupKey, see uniqueKeyProblems
+func checkUniqueKey(ctx context.Context, db *sqlx.DB, dl Dialect, opts Options) error {
+	if opts.Warnf == nil {
+		return nil
+	}
+
+	problems, err := uniqueKeyProblems(ctx, db, dl, opts)
+	if err != nil {
+		return err
+	}
+	for _, msg := range problems {
+		opts.Warnf("%s", msg)
+	}
+
+	return nil
+}
+
+// insertRecords - insert records of src in one transaction, returns number of inserted records per source file
+func insertRecords(ctx context.Context, db *sqlx.DB, dl Dialect, opts Options, src Source) (map[string]int, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error starting transaction: %w", err)
+	}
+	defer func() {
+		// no-op after successful commit
+		_ = tx.Rollback()
+	}()
+
+	// inserted records are counted by number of rows in table before and after each file,
+	// RowsAffected is not reliable for "ON CONFLICT DO NOTHING" across drivers
+	countRows := func() (int, error) {
+		cnt := 0
+		if err := tx.GetContext(ctx, &cnt, dl.CountSQL()); err != nil {
+			return 0, fmt.Errorf("Error counting rows: %w", err)
+		}
+		return cnt, nil
+	}
+
+	lastCount, err := countRows()
+	if err != nil {
+		return nil, err
+	}
+	if opts.FastLoad && lastCount > 0 {
+		return nil, fmt.Errorf("Table %s is not empty (%d rows), fast load is only for import to empty table", opts.Table, lastCount)
+	}
+
+	// records are inserted by batches with multi-row VALUES, batch doesn't cross file boundary,
+	// with Record callback each record is inserted separately for reporting of inserted/skipped records
+	perRecord := opts.Record != nil && !opts.FastLoad
+	size := batchSize
+	if perRecord {
+		size = 1
+	}
+
+	// prepared statements by batch size, preparing of long multi-row INSERT is slow (in SQLite)
+	stmts := map[int]*sqlx.Stmt{}
+	defer func() {
+		for _, stmt := range stmts {
+			_ = stmt.Close()
+		}
+	}()
+	// returns number of affected rows
+	insertBatch := func(batch []monoparse.Record) (int64, error) {
+		query, args, err := sqlx.Named(dl.InsertSQL(), batch)
+		if err != nil {
+			return 0, err
+		}
+
+		stmt, ok := stmts[len(batch)]
+		if !ok {
+			if stmt, err = tx.PreparexContext(ctx, tx.Rebind(query)); err != nil {
+				return 0, err
+			}
+			stmts[len(batch)] = stmt
+		}
+
+		res, err := stmt.ExecContext(ctx, args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	// records of each file (run of records with the same SourceFile) are inserted by batches,
+	// inserted records of the file are the difference of row counts before and after them,
+	// with fast load all records are inserted, so rows are not counted
+	inserted, files := map[string]int{}, []string{}
+	batch := make([]monoparse.Record, 0, size)
+	done, fileCnt := 0, 0     // processed records, records of the current file
+	var first, last time.Time // range of operation times, for matching of transfers
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		affected, err := insertBatch(batch)
+		if err != nil {
+			if len(batch) == 1 {
+				return fmt.Errorf("Error inserting record %#v: %w", batch[0], err)
+			}
+			return fmt.Errorf("Error inserting records %d-%d: %w", done, done+len(batch)-1, err)
+		}
+		done += len(batch)
+		if opts.Progress != nil {
+			opts.Progress(done)
+		}
+		// one record in batch, so RowsAffected is of this record
+		if perRecord {
+			opts.Record(batch[0], affected > 0)
+		}
+		if opts.FastLoad && opts.Record != nil {
+			for _, rec := range batch {
+				opts.Record(rec, true)
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	endFile := func() error {
+		if err := flush(); err != nil || len(files) == 0 {
+			return err
+		}
+
+		file := files[len(files)-1]
+		if opts.FastLoad {
+			inserted[file] += fileCnt
+			return nil
+		}
+		cnt, err := countRows()
+		if err != nil {
+			return err
+		}
+		inserted[file] += cnt - lastCount
+		lastCount = cnt
+		return nil
+	}
+
+	err = src(func(rec monoparse.Record) error {
+		if len(files) == 0 || rec.SourceFile != files[len(files)-1] {
+			if err := endFile(); err != nil {
+				return err
+			}
+			files, fileCnt = append(files, rec.SourceFile), 0
+		}
+		if first.IsZero() || rec.CreatedAt.Before(first) {
+			first = rec.CreatedAt
+		}
+		if rec.CreatedAt.After(last) {
+			last = rec.CreatedAt
+		}
+		batch = append(batch, rec)
+		fileCnt++
+		if len(batch) == size {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = endFile()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.FastLoad {
+		if err := migrateIndexes(ctx, tx, dl); err != nil {
+			return nil, err
+		}
+	}
+
+	// transfers are matched with records of previous imports of other accounts near operation times of imported records
+	if opts.MarkTransfers && done > 0 {
+		pairs, err := markTransfers(ctx, tx, opts, first, last)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Logf != nil {
+			opts.Logf("Marked %d transfers between accounts (%d records)", pairs, pairs*2)
+		}
+	}
+
+	// the run is recorded in the same transaction, so it's recorded only if records are saved
+	if opts.RecordRun {
+		if err := recordRun(ctx, tx, opts, files, done, inserted); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Error committing transaction: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// recordRun - insert row of import run to MetaTable, files are source files of parsed records in order of import
+func recordRun(ctx context.Context, tx *sqlx.Tx, opts Options, files []string, parsed int, inserted map[string]int) error {
+	run := ImportRun{
+		ImportedAt: time.Now().Truncate(time.Second),
+		Version:    opts.Version,
+		TableName:  opts.Table,
+		Parsed:     parsed,
+	}
+
+	run.Files = strings.Join(files, ", ")
+	for _, cnt := range inserted {
+		run.Inserted += cnt
+	}
+
+	query, args, err := sqlx.Named(insertMetaSQL, run)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("Error recording import to %s: %w", MetaTable, err)
+	}
+
+	return nil
+}
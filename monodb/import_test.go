@@ -0,0 +1,232 @@
+package monodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// openTestDB - in-memory SQLite DB, one connection, each connection has its own DB
+func openTestDB(tb testing.TB) *sqlx.DB {
+	tb.Helper()
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	tb.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// testRecords - n distinct records of file, one per minute
+func testRecords(file string, n int) []monoparse.Record {
+	start := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	recs := make([]monoparse.Record, 0, n)
+	for i := 0; i < n; i++ {
+		recs = append(recs, monoparse.Record{
+			CreatedAt:  start.Add(time.Duration(i) * time.Minute),
+			Title:      fmt.Sprintf("Shop %d", i),
+			MCC:        5411,
+			Amount:     -100 - i,
+			AmountOrig: -100 - i,
+			Currency:   "UAH",
+			Rest:       100000 - i,
+			SourceFile: file,
+		})
+	}
+
+	return recs
+}
+
+func TestImportRecordCallback(t *testing.T) {
+	db := openTestDB(t)
+	opts := Options{Table: "mono"}
+	if _, err := Import(db, testRecords("a.csv", 3), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first 3 records are in DB already
+	recs := testRecords("b.csv", 5)
+	reported := map[string]bool{}
+	opts.Record = func(rec monoparse.Record, inserted bool) {
+		reported[rec.Title] = inserted
+	}
+	inserted, err := ImportContext(context.Background(), db, recs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inserted["b.csv"] != 2 {
+		t.Errorf("expected 2 inserted records, got %d", inserted["b.csv"])
+	}
+	for i, rec := range recs {
+		if want := i >= 3; reported[rec.Title] != want {
+			t.Errorf("record %q: reported inserted=%v, expected %v", rec.Title, reported[rec.Title], want)
+		}
+	}
+}
+
+func TestImportCountsPerFile(t *testing.T) {
+	db := openTestDB(t)
+	opts := Options{Table: "mono"}
+	if _, err := Import(db, testRecords("old.csv", 150), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// more records than batch size, a.csv overlaps with DB, b.csv is new and c.csv duplicates a.csv
+	recs := append(testRecords("a.csv", 250), testRecords("b.csv", 300)[250:]...)
+	recs = append(recs, testRecords("c.csv", 10)...)
+	inserted, err := ImportContext(context.Background(), db, recs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]int{"a.csv": 100, "b.csv": 50, "c.csv": 0} {
+		if inserted[file] != want {
+			t.Errorf("%s: expected %d inserted records, got %d", file, want, inserted[file])
+		}
+	}
+
+	// with fast load all records of file are counted as inserted
+	fresh := openTestDB(t)
+	inserted, err = ImportContext(context.Background(), fresh, testRecords("a.csv", 250), Options{Table: "mono", FastLoad: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted["a.csv"] != 250 {
+		t.Errorf("fast load: expected 250 inserted records, got %d", inserted["a.csv"])
+	}
+}
+
+func TestImportFromSourceError(t *testing.T) {
+	db := openTestDB(t)
+	opts := Options{Table: "mono"}
+	if _, err := Import(db, testRecords("a.csv", 3), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// error of source after more records than batch size, nothing is saved
+	errRead := errors.New("read error")
+	recs := testRecords("b.csv", 300)
+	src := func(fn func(monoparse.Record) error) error {
+		for _, rec := range recs[:250] {
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return errRead
+	}
+	if _, err := ImportFrom(context.Background(), db, src, opts); !errors.Is(err, errRead) {
+		t.Fatalf("expected error of source, got %v", err)
+	}
+
+	var cnt int
+	if err := db.Get(&cnt, "SELECT COUNT(*) FROM mono"); err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 3 {
+		t.Errorf("expected 3 records after failed import, got %d", cnt)
+	}
+
+	// source is read as slice
+	inserted, err := ImportFrom(context.Background(), db, Records(recs), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted["b.csv"] != 297 {
+		t.Errorf("expected 297 inserted records, got %d", inserted["b.csv"])
+	}
+}
+
+const benchRecords = 100_000
+
+// benchmarkImport - import of benchRecords records into empty table for each iteration
+func benchmarkImport(b *testing.B, opts Options) {
+	recs := testRecords("bench.csv", benchRecords)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db := openTestDB(b)
+		b.StartTimer()
+
+		inserted, err := ImportContext(context.Background(), db, recs, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if inserted["bench.csv"] != benchRecords {
+			b.Fatalf("expected %d inserted records, got %d", benchRecords, inserted["bench.csv"])
+		}
+	}
+}
+
+func BenchmarkImportBatched(b *testing.B) {
+	benchmarkImport(b, Options{Table: "mono"})
+}
+
+// per-row INSERT, as with Options.Record callback
+func BenchmarkImportPerRow(b *testing.B) {
+	benchmarkImport(b, Options{Table: "mono", Record: func(monoparse.Record, bool) {}})
+}
+
+func BenchmarkImportFastLoad(b *testing.B) {
+	benchmarkImport(b, Options{Table: "mono", FastLoad: true})
+}
+
+func TestImportUniqueKeyMismatch(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Import(db, testRecords("a.csv", 3), Options{Table: "mono"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts Options
+		hint string
+	}{
+		{Options{Table: "mono", DedupKey: DedupFullHash}, "created with dedup key date+title+amount:"},
+		{Options{Table: "mono", DedupKey: DedupDateTitleAmountRest}, "created with dedup key date+title+amount:"},
+		{Options{Table: "mono", Account: true}, "created with dedup key date+title+amount:"},
+		{Options{Table: "mono", DedupKey: DedupNone}, "created with dedup key date+title+amount:"},
+	}
+	for _, tt := range tests {
+		_, err := Import(db, testRecords("b.csv", 5), tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.hint) {
+			t.Errorf("dedup key %q (account: %v): expected error with %q, got %v", tt.opts.DedupKey, tt.opts.Account, tt.hint, err)
+		}
+	}
+
+	// the table isn't changed
+	columns, err := tableColumnNames(context.Background(), db, mustDialect(t, db, Options{Table: "mono"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns["hash"] || columns["account"] {
+		t.Errorf("columns are added to the table on refused import: %v", columns)
+	}
+
+	// table created with other key is reported with it
+	hashDB := openTestDB(t)
+	if _, err := Import(hashDB, testRecords("a.csv", 3), Options{Table: "mono", DedupKey: DedupFullHash}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(hashDB, testRecords("a.csv", 3), Options{Table: "mono"}); err == nil || !strings.Contains(err.Error(), "created with dedup key full-hash:") {
+		t.Errorf("expected error with dedup key of the table, got %v", err)
+	}
+}
+
+func mustDialect(tb testing.TB, db *sqlx.DB, opts Options) Dialect {
+	tb.Helper()
+
+	dl, err := NewDialect(db.DriverName(), opts)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return dl
+}
@@ -0,0 +1,68 @@
+package monodb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LatestTime - operation time of the latest record in the table, of the account if it isn't empty,
+// loc is time zone of operation times of the records (see dbTime), UTC if nil,
+// ok is false if the table doesn't exist or has no such records, DB isn't changed
+func LatestTime(ctx context.Context, db *sqlx.DB, opts Options, account string, loc *time.Location) (latest time.Time, ok bool, err error) {
+	dl, err := NewDialect(db.DriverName(), opts)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	columns, err := tableColumnNames(ctx, db, dl)
+	if err != nil || len(columns) == 0 {
+		return time.Time{}, false, err
+	}
+
+	// ORDER BY instead of MAX(), SQLite returns DATETIME as text from aggregates
+	query, args := "SELECT created_at FROM "+opts.Table, []any{}
+	if account != "" {
+		// the column is added on import with account
+		if !columns["account"] {
+			return time.Time{}, false, nil
+		}
+		query, args = query+" WHERE account = ?", append(args, account)
+	}
+	query += " ORDER BY created_at DESC LIMIT 1"
+
+	var createdAt any
+	if err := db.QueryRowxContext(ctx, db.Rebind(query), args...).Scan(&createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, fmt.Errorf("Error selecting latest record: %w", err)
+	}
+
+	latest, err = dbTime(db.DriverName(), driverValue(createdAt), loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return latest, true, nil
+}
+
+// dbTime - scanned created_at as time in loc: PostgreSQL TIMESTAMP (lib/pq) and MySQL DATETIME text
+// (without parseTime DSN parameter) are wall clock of operation in UTC, it's re-interpreted in loc.
+// SQLite keeps offset of time and MySQL driver with parseTime converts it to loc DSN parameter, they aren't changed
+func dbTime(driver string, v any, loc *time.Location) (time.Time, error) {
+	t, err := scannedTime(v)
+	if err != nil || loc == nil {
+		return t, err
+	}
+
+	if _, text := v.(string); driver == "postgres" || (driver == "mysql" && text) {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+
+	return t, nil
+}
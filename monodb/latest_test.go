@@ -0,0 +1,79 @@
+package monodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDBTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Kiev")
+	if err != nil {
+		t.Skip(err)
+	}
+	// the latest operation at 23:30 of local time, which is the next day in UTC+2 if read as UTC
+	latest := time.Date(2024, time.February, 1, 23, 30, 0, 0, loc)
+	wall := time.Date(2024, time.February, 1, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		driver string
+		value  any
+	}{
+		{"postgres TIMESTAMP", "postgres", wall},
+		{"mysql text", "mysql", "2024-02-01 23:30:00"},
+		{"mysql with parseTime", "mysql", latest.UTC()},
+		{"sqlite", "sqlite3", latest},
+	}
+	for _, tt := range tests {
+		got, err := dbTime(tt.driver, tt.value, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(latest) {
+			t.Errorf("%s: dbTime = %s, expected %s", tt.name, got, latest)
+		}
+
+		// record 15 minutes after the latest one is newer, -tail compares CreatedAt with it
+		if next := latest.Add(15 * time.Minute); !next.After(got) {
+			t.Errorf("%s: record at %s is not after the latest one %s", tt.name, next, got)
+		}
+		if prev := latest.Add(-15 * time.Minute); prev.After(got) {
+			t.Errorf("%s: record at %s is after the latest one %s", tt.name, prev, got)
+		}
+	}
+
+	// without time zone values are not changed
+	if got, err := dbTime("postgres", wall, nil); err != nil || !got.Equal(wall) {
+		t.Errorf("dbTime without location = %s, %v, expected %s", got, err, wall)
+	}
+}
+
+func TestLatestTime(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Kiev")
+	if err != nil {
+		t.Skip(err)
+	}
+	db := openTestDB(t)
+	opts := Options{Table: "mono"}
+
+	if _, ok, err := LatestTime(context.Background(), db, opts, "", loc); err != nil || ok {
+		t.Fatalf("LatestTime of missing table: ok=%v, err=%v", ok, err)
+	}
+
+	recs := testRecords("a.csv", 3)
+	for i := range recs {
+		recs[i].CreatedAt = time.Date(2024, time.February, 1, 23, 30+i, 0, 0, loc)
+	}
+	if _, err := Import(db, recs, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, ok, err := LatestTime(context.Background(), db, opts, "", loc)
+	if err != nil || !ok {
+		t.Fatalf("LatestTime: ok=%v, err=%v", ok, err)
+	}
+	if want := recs[2].CreatedAt; !latest.Equal(want) {
+		t.Errorf("LatestTime = %s, expected %s", latest, want)
+	}
+}
@@ -0,0 +1,153 @@
+package monodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SchemaProblems - differences of existing table from the table expected by opts: missing table, columns, indexes
+// and unique key of other dedup key, with hints how to fix them, empty if the table matches, DB isn't changed
+func SchemaProblems(ctx context.Context, db *sqlx.DB, opts Options) ([]string, error) {
+	dl, err := NewDialect(db.DriverName(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := tableColumnNames(ctx, db, dl)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return []string{fmt.Sprintf("Table %s doesn't exist, it's created on import", opts.Table)}, nil
+	}
+
+	problems, missing := []string{}, []string{}
+	for _, col := range dl.Columns() {
+		if !columns[col.name] {
+			missing = append(missing, col.name)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("Columns are missing: %s, the table is from an older version or created without these options, columns are added on import", strings.Join(missing, ", ")))
+	}
+
+	indexes := []string{}
+	if err := db.SelectContext(ctx, &indexes, dl.IndexNamesSQL()); err != nil {
+		return nil, fmt.Errorf("Error getting table indexes: %w", err)
+	}
+	exists := map[string]bool{}
+	for _, name := range indexes {
+		exists[strings.ToLower(name)] = true
+	}
+	for _, idx := range dl.Indexes() {
+		if !exists[strings.ToLower(idx.name)] {
+			problems = append(problems, fmt.Sprintf("Index %s is missing, it's created on import", idx.name))
+		}
+	}
+
+	keyProblems, err := uniqueKeyProblems(ctx, db, dl, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(problems, keyProblems...), nil
+}
+
+// tableColumnNames - lower case names of the table columns, empty if the table doesn't exist
+func tableColumnNames(ctx context.Context, db sqlx.QueryerContext, dl Dialect) (map[string]bool, error) {
+	existing := []string{}
+	if err := sqlx.SelectContext(ctx, db, &existing, dl.ColumnsSQL()); err != nil {
+		return nil, fmt.Errorf("Error getting table columns: %w", err)
+	}
+
+	columns := map[string]bool{}
+	for _, name := range existing {
+		columns[strings.ToLower(name)] = true
+	}
+
+	return columns, nil
+}
+
+// uniqueKeyProblems - unique indexes of the table which don't match DedupKey, e.g. the table was created
+// with other dedup key, and missing unique key of DedupKey
+func uniqueKeyProblems(ctx context.Context, db sqlx.QueryerContext, dl Dialect, opts Options) ([]string, error) {
+	indexes, err := uniqueIndexes(ctx, db, dl)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := uniqueColumns(opts)
+	problems := []string{}
+	found := false
+	for _, columns := range indexes {
+		if columns == expected {
+			found = true
+		} else {
+			problems = append(problems, uniqueKeyMismatch(opts, columns))
+		}
+	}
+	if !found && expected != "" {
+		problems = append(problems, fmt.Sprintf("Table %s has no unique key (%s) of dedup key %s, the table may need to be rebuilt", opts.Table, expected, dedupKeyName(opts)))
+	}
+
+	return problems, nil
+}
+
+// uniqueIndexes - columns of unique indexes of the table, comma-separated in lower case, empty if the table doesn't exist
+func uniqueIndexes(ctx context.Context, db sqlx.QueryerContext, dl Dialect) ([]string, error) {
+	rows := []struct {
+		Index  string `db:"index_name"`
+		Column string `db:"column_name"`
+	}{}
+	if err := sqlx.SelectContext(ctx, db, &rows, dl.UniqueIndexesSQL()); err != nil {
+		return nil, fmt.Errorf("Error getting table unique indexes: %w", err)
+	}
+
+	indexes, names := map[string][]string{}, []string{}
+	for _, row := range rows {
+		name := strings.ToLower(row.Index)
+		if _, ok := indexes[name]; !ok {
+			names = append(names, name)
+		}
+		indexes[name] = append(indexes[name], strings.ToLower(row.Column))
+	}
+
+	result := []string{}
+	for _, name := range names {
+		result = append(result, strings.Join(indexes[name], ", "))
+	}
+
+	return result, nil
+}
+
+// uniqueKeyMismatch - message about unique key of the table which doesn't match DedupKey,
+// with dedup key of the table if it's one of known keys
+func uniqueKeyMismatch(opts Options, columns string) string {
+	msg := fmt.Sprintf("Table %s has unique key (%s) which doesn't match dedup key %s", opts.Table, columns, dedupKeyName(opts))
+	for _, key := range []DedupKey{DedupDateTitleAmount, DedupDateTitleAmountRest, DedupFullHash} {
+		for _, account := range []bool{false, true} {
+			if uniqueColumns(Options{DedupKey: key, Account: account}) != columns {
+				continue
+			}
+			tableKey := string(key)
+			if account {
+				tableKey += " with account"
+			}
+			return fmt.Sprintf("%s, the table is created with dedup key %s: import with it, or to a new table, or rebuild the table", msg, tableKey)
+		}
+	}
+
+	return msg + ", import to a new table or rebuild the table"
+}
+
+// dedupKeyName - DedupKey of opts, DedupDateTitleAmount if empty
+func dedupKeyName(opts Options) DedupKey {
+	if opts.DedupKey == "" {
+		return DedupDateTitleAmount
+	}
+
+	return opts.DedupKey
+}
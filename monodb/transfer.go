@@ -0,0 +1,160 @@
+package monodb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// TransferWindow - max difference of operation times of outgoing and incoming records of one transfer
+const TransferWindow = 5 * time.Minute
+
+// transferRow - record of the table which isn't marked as transfer yet,
+// created_at and amount are passed back to UPDATE as scanned, so they match the stored values exactly
+type transferRow struct {
+	CreatedAt any    `db:"created_at"`
+	Title     string `db:"title"`
+	Amount    any    `db:"amount"`
+	Account   string `db:"account"`
+
+	time   time.Time
+	amount int // in kopecks, negative for outgoing operations
+}
+
+// markTransfers - heuristic matching of transfers between accounts (e.g. card and jar) in records of the table
+// with operation times from first to last (imported records) widened by TransferWindow, so only records near
+// imported ones are read, not the whole history: outgoing and incoming records of different accounts with
+// the same absolute amount and operation times within TransferWindow, the nearest by time, are marked
+// by transfer column, returns number of marked pairs
+func markTransfers(ctx context.Context, tx *sqlx.Tx, opts Options, first, last time.Time) (int, error) {
+	if !opts.Account {
+		return 0, fmt.Errorf("Transfers are matched between accounts, account column is required")
+	}
+
+	rows := []transferRow{}
+	query := tx.Rebind("SELECT created_at, title, amount, COALESCE(account, '') AS account FROM " + opts.Table +
+		" WHERE created_at BETWEEN ? AND ? AND amount <> 0 AND (transfer IS NULL OR transfer = FALSE) ORDER BY created_at")
+	if err := tx.SelectContext(ctx, &rows, query, first.Add(-TransferWindow), last.Add(TransferWindow)); err != nil {
+		return 0, fmt.Errorf("Error selecting records for transfers: %w", err)
+	}
+
+	coef := float64(monoparse.CentsCoef)
+	if opts.Kopecks {
+		coef = 1
+	}
+	// incoming records by amount, in order of time
+	incoming := map[int][]int{}
+	for i := range rows {
+		row := &rows[i]
+		row.CreatedAt, row.Amount = driverValue(row.CreatedAt), driverValue(row.Amount)
+
+		var err error
+		if row.time, err = scannedTime(row.CreatedAt); err != nil {
+			return 0, err
+		}
+		amount, err := scannedNumber(row.Amount)
+		if err != nil {
+			return 0, err
+		}
+		row.amount = int(math.Round(amount * coef))
+		if row.amount > 0 {
+			incoming[row.amount] = append(incoming[row.amount], i)
+		}
+	}
+
+	update, err := tx.PreparexContext(ctx, tx.Rebind("UPDATE "+opts.Table+
+		" SET transfer = TRUE WHERE created_at = ? AND title = ? AND amount = ? AND COALESCE(account, '') = ?"))
+	if err != nil {
+		return 0, fmt.Errorf("Error preparing update of transfers: %w", err)
+	}
+	defer update.Close()
+
+	used := map[int]bool{}
+	pairs := 0
+	for _, row := range rows {
+		if row.amount >= 0 {
+			continue
+		}
+
+		match, best := -1, TransferWindow
+		for _, j := range incoming[-row.amount] {
+			in := rows[j]
+			if used[j] || in.Account == row.Account {
+				continue
+			}
+			if d := absDuration(in.time.Sub(row.time)); d <= best {
+				match, best = j, d
+			}
+		}
+		if match < 0 {
+			continue
+		}
+
+		used[match] = true
+		for _, rec := range []transferRow{row, rows[match]} {
+			if _, err := update.ExecContext(ctx, rec.CreatedAt, rec.Title, rec.Amount, rec.Account); err != nil {
+				return 0, fmt.Errorf("Error marking transfer %s %q: %w", rec.time, rec.Title, err)
+			}
+		}
+		pairs++
+	}
+
+	return pairs, nil
+}
+
+// driverValue - bytes are scanned from DECIMAL (PostgreSQL, MySQL) and DATETIME (MySQL) columns,
+// they are passed back as strings, bytes are bytea parameters in PostgreSQL
+func driverValue(v any) any {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	return v
+}
+
+// scannedTime - created_at is time.Time, or text for MySQL without parseTime DSN parameter (in UTC)
+func scannedTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.DateTime, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Error parsing created_at %q: %w", v, err)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("Unsupported type of created_at: %T", v)
+	}
+}
+
+// scannedNumber - amount is float or integer, or text of DECIMAL
+func scannedNumber(v any) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Error parsing amount %q: %w", v, err)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("Unsupported type of amount: %T", v)
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
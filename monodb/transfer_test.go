@@ -0,0 +1,45 @@
+package monodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+func TestMarkTransfers(t *testing.T) {
+	db := openTestDB(t)
+	at := func(day, minute int) time.Time {
+		return time.Date(2024, time.February, day, 10, minute, 0, 0, time.UTC)
+	}
+	record := func(account string, created time.Time, amount int) monoparse.Record {
+		return monoparse.Record{CreatedAt: created, Title: "Transfer", Amount: amount, AmountOrig: amount, Currency: "UAH", Account: account, SourceFile: account + ".csv"}
+	}
+
+	// transfer of history imported without marking of transfers
+	opts := Options{Table: "mono", Account: true}
+	if _, err := Import(db, []monoparse.Record{record("card", at(1, 0), -50000), record("jar", at(1, 1), 50000)}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// outgoing and incoming records of a new transfer are imported separately, with a record of other amount
+	opts.MarkTransfers = true
+	if _, err := Import(db, []monoparse.Record{record("card", at(10, 0), -30000), record("card", at(10, 1), -100)}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(db, []monoparse.Record{record("jar", at(10, 3), 30000)}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	marked := []struct {
+		Account string `db:"account"`
+		Amount  int    `db:"amount"`
+	}{}
+	if err := db.Select(&marked, "SELECT account, CAST(amount * 100 AS INTEGER) AS amount FROM mono WHERE transfer = TRUE ORDER BY created_at"); err != nil {
+		t.Fatal(err)
+	}
+	// records of history far from imported ones are not read
+	if len(marked) != 2 || marked[0].Account != "card" || marked[0].Amount != -30000 || marked[1].Account != "jar" || marked[1].Amount != 30000 {
+		t.Errorf("unexpected marked records: %+v", marked)
+	}
+}
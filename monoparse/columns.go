@@ -0,0 +1,108 @@
+package monoparse
+
+import (
+	"fmt"
+	"strings"
+)
+
+type column int
+
+const (
+	colCreatedAt column = iota
+	colTitle
+	colMCC
+	colAmount
+	colAmountOrig
+	colCurrency
+	colExchange
+	colCommission
+	colCashback
+	colRest
+	columnsCount
+)
+
+// StatementType - kind of monobank statement, each has its own set of columns
+type StatementType int
+
+const (
+	CardStatement StatementType = iota // card statement, default
+	JarStatement                       // jar ("банка") statement, without MCC and cashback
+)
+
+// optionalColumns - columns which may be absent for statement type, their values are empty
+var optionalColumns = map[StatementType][]column{
+	JarStatement: {colMCC, colAmountOrig, colCurrency, colExchange, colCommission, colCashback},
+}
+
+// Columns - index of each column in CSV row, -1 for absent optional column
+type Columns [columnsCount]int
+
+// DefaultColumns - columns order of monobank card statement
+var DefaultColumns = Columns{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+// ParseHeader - find columns of card statement by CSV header names
+func ParseHeader(header []string) (Columns, error) {
+	return CardStatement.ParseHeader(header)
+}
+
+// ParseHeader - find columns by monobank CSV header names, see Profile.ParseHeader
+func (t StatementType) ParseHeader(header []string) (Columns, error) {
+	return MonobankProfile.ParseHeader(t, header)
+}
+
+// ParseHeader - find columns by header names of profile, all columns except optional for statement type
+// are required, unknown columns (e.g. added by newer monobank versions) are ignored
+func (prof Profile) ParseHeader(t StatementType, header []string) (Columns, error) {
+	cols := Columns{}
+	for col := range cols {
+		cols[col] = -1
+	}
+
+	for i, name := range header {
+		name = normalizeHeader(name)
+		for col := range cols {
+			for _, known := range prof.Headers[columnFields[col]] {
+				if name != known {
+					continue
+				}
+				if cols[col] != -1 {
+					return cols, fmt.Errorf("Column %q is duplicated in CSV header: fields %d and %d", prof.headerName(column(col)), cols[col]+1, i+1)
+				}
+				cols[col] = i
+			}
+		}
+	}
+
+	optional := map[column]bool{}
+	for _, col := range optionalColumns[t] {
+		optional[col] = true
+	}
+
+	for col, i := range cols {
+		if i == -1 && !optional[column(col)] {
+			return cols, fmt.Errorf("Column %q not found in CSV header", prof.headerName(column(col)))
+		}
+	}
+
+	return cols, nil
+}
+
+// value - value of column in row, empty for absent column
+func (cols Columns) value(row []string, col column) string {
+	if cols[col] == -1 {
+		return ""
+	}
+
+	return row[cols[col]]
+}
+
+func normalizeHeader(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(name, ")") {
+		if i := strings.LastIndex(name, " ("); i > 0 {
+			name = name[:i]
+		}
+	}
+
+	return name
+}
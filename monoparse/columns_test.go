@@ -0,0 +1,48 @@
+package monoparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSVExtraColumn(t *testing.T) {
+	records := readFixture(t, Parser{StrictFields: true}, "extra_column.csv")
+
+	want := readFixture(t, Parser{}, "statement.csv")
+	want[2].Title = "Від: Іван" // title differs in fixtures
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records with unknown trailing column differ from statement without it:\n%+v\n%+v", records, want)
+	}
+}
+
+func TestParseHeaderColumnsOrder(t *testing.T) {
+	header := []string{"Коментар", "Дата i час операції", "Деталі операції", "MCC", "Сума в валюті картки (UAH)",
+		"Сума в валюті операції", "Валюта", "Курс", "Сума комісій (UAH)", "Сума кешбеку (UAH)", "Залишок після операції"}
+	cols, err := ParseHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Columns{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}); cols != want {
+		t.Errorf("columns of header with unknown leading column: %v, expected %v", cols, want)
+	}
+}
+
+func TestParseHeaderErrors(t *testing.T) {
+	header := []string{"Дата i час операції", "Деталі операції", "MCC", "Сума в валюті картки (UAH)",
+		"Сума в валюті операції", "Валюта", "Курс", "Сума комісій (UAH)", "Сума кешбеку (UAH)", "Залишок після операції"}
+
+	tests := []struct {
+		name   string
+		header []string
+		err    string
+	}{
+		{"duplicated column", append(append([]string{}, header...), "MCC"), "duplicated"},
+		{"missing required column", append(append([]string{}, header[:2]...), header[3:]...), `"MCC" not found`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseHeader(tt.header); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error with %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
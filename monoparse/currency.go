@@ -0,0 +1,21 @@
+package monoparse
+
+import (
+	"regexp"
+)
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// knownCurrencies - ISO 4217 codes often found in monobank statements
+var knownCurrencies = map[string]bool{
+	"UAH": true, "USD": true, "EUR": true, "GBP": true, "PLN": true,
+	"CHF": true, "CZK": true, "HUF": true, "RON": true, "BGN": true,
+	"SEK": true, "NOK": true, "DKK": true, "TRY": true, "GEL": true,
+	"MDL": true, "CAD": true, "AUD": true, "JPY": true, "CNY": true,
+	"ILS": true, "AED": true, "EGP": true, "THB": true, "KZT": true,
+}
+
+// ValidCurrency - currency is empty (jar statements), a known code or any 3 uppercase letters code
+func ValidCurrency(currency string) bool {
+	return currency == "" || knownCurrencies[currency] || currencyRe.MatchString(currency)
+}
@@ -0,0 +1,158 @@
+package monoparse
+
+import (
+	"regexp"
+)
+
+const unknownCategory = "unknown"
+
+var mccRe = regexp.MustCompile(`^[0-9]{3,4}$`)
+
+// ValidMCC - MCC is empty or 3-4 digit code
+func ValidMCC(mcc string) bool {
+	return mcc == "" || mcc == "—" || mcc == "-" || mccRe.MatchString(mcc)
+}
+
+// mccCategories - category names for common MCC codes, extend as needed
+var mccCategories = map[int]string{
+	// groceries
+	5411: "groceries",
+	5422: "groceries",
+	5441: "groceries",
+	5451: "groceries",
+	5462: "groceries",
+	5499: "groceries",
+
+	// restaurants
+	5812: "restaurants",
+	5813: "restaurants",
+	5814: "restaurants",
+
+	// transport
+	4111: "transport",
+	4112: "transport",
+	4121: "transport",
+	4131: "transport",
+	4511: "transport",
+	4784: "transport",
+	4789: "transport",
+	7523: "transport",
+
+	// fuel
+	5172: "fuel",
+	5541: "fuel",
+	5542: "fuel",
+	5983: "fuel",
+
+	// health
+	5912: "health",
+	8011: "health",
+	8021: "health",
+	8062: "health",
+	8071: "health",
+	8099: "health",
+
+	// clothing
+	5611: "clothing",
+	5621: "clothing",
+	5651: "clothing",
+	5661: "clothing",
+	5691: "clothing",
+	5699: "clothing",
+
+	// electronics
+	5045: "electronics",
+	5732: "electronics",
+	5734: "electronics",
+
+	// digital goods and subscriptions
+	5815: "digital",
+	5816: "digital",
+	5817: "digital",
+	5818: "digital",
+
+	// entertainment
+	7832: "entertainment",
+	7922: "entertainment",
+	7941: "entertainment",
+	7996: "entertainment",
+	7999: "entertainment",
+
+	// utilities and telecom
+	4812: "telecom",
+	4814: "telecom",
+	4899: "telecom",
+	4900: "utilities",
+
+	// shopping
+	5300: "shopping",
+	5310: "shopping",
+	5311: "shopping",
+	5331: "shopping",
+	5399: "shopping",
+
+	// home
+	5200: "home",
+	5211: "home",
+	5251: "home",
+	5712: "home",
+	5722: "home",
+
+	// beauty
+	5977: "beauty",
+	7230: "beauty",
+
+	// sport
+	5941: "sport",
+	7997: "sport",
+
+	// books
+	5942: "books",
+	5994: "books",
+
+	// pets
+	742:  "pets",
+	5995: "pets",
+
+	// education
+	8211: "education",
+	8220: "education",
+	8299: "education",
+
+	// transfers and cash
+	4829: "transfers",
+	6012: "transfers",
+	6536: "transfers",
+	6537: "transfers",
+	6538: "transfers",
+	6010: "cash",
+	6011: "cash",
+
+	// travel
+	4722: "travel",
+	7011: "travel",
+
+	// government, insurance
+	9222: "government",
+	9311: "government",
+	9399: "government",
+	6300: "insurance",
+}
+
+// mccCategory - category name by MCC code, "unknown" for unknown codes
+func mccCategory(mcc int) string {
+	if category, ok := mccCategories[mcc]; ok {
+		return category
+	}
+
+	switch {
+	case mcc >= 3000 && mcc <= 3350: // airlines
+		return "transport"
+	case mcc >= 3351 && mcc <= 3500: // car rental
+		return "transport"
+	case mcc >= 3501 && mcc <= 3999: // hotels
+		return "travel"
+	}
+
+	return unknownCategory
+}
@@ -0,0 +1,51 @@
+package monoparse
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MerchantMap - canonical merchant names by spelling of title, see ReadMerchantMap
+type MerchantMap map[string]string
+
+// ReadMerchantMap - read CSV with "title,name" rows, e.g. `ATB,АТБ`, lines starting with # are comments,
+// titles are matched after normalization of whitespace and case-insensitive, the whole title must match
+func ReadMerchantMap(r io.Reader) (MerchantMap, error) {
+	csvr := csv.NewReader(r)
+	csvr.Comment = '#'
+	csvr.FieldsPerRecord = 2
+
+	names := MerchantMap{}
+	for {
+		row, err := csvr.Read()
+		if errors.Is(err, io.EOF) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := csvr.FieldPos(0)
+		title, name := merchantKey(row[0]), normalizeTitle(row[1])
+		if title == "" || name == "" {
+			return nil, fmt.Errorf("Empty title or name in line %d", line)
+		}
+		if prev, ok := names[title]; ok && prev != name {
+			return nil, fmt.Errorf("Title %q in line %d has other name already: %q", row[0], line, prev)
+		}
+		names[title] = name
+	}
+}
+
+// Name - canonical name for title, ok is false for titles which aren't in the map
+func (m MerchantMap) Name(title string) (string, bool) {
+	name, ok := m[merchantKey(title)]
+	return name, ok
+}
+
+func merchantKey(title string) string {
+	return strings.ToLower(normalizeTitle(title))
+}
@@ -0,0 +1,40 @@
+package monoparse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCSVTitleCase(t *testing.T) {
+	merchants, err := ReadMerchantMap(strings.NewReader("ATB,АТБ\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := `"Дата i час операції","Деталі операції",MCC,"Сума в валюті картки (UAH)","Сума в валюті операції",Валюта,Курс,"Сума комісій (UAH)","Сума кешбеку (UAH)","Залишок після операції"`
+	rows := []string{
+		`"01.02.2024 10:00:00","СІЛЬПО  KYIV",5411,-120.50,-120.50,UAH,—,—,—,1000.00`,
+		`"01.02.2024 11:00:00","atb",5411,-20.00,-20.00,UAH,—,—,—,980.00`,
+		`"01.02.2024 12:00:00","google *youtube",5818,-41.10,-1.00,USD,41.1000,—,—,938.90`,
+		`"01.02.2024 13:00:00","Від: ІВАН ПЕТРЕНКО",4829,500.00,500.00,UAH,—,—,—,1438.90`,
+	}
+	data := header + "\n" + strings.Join(rows, "\n") + "\n"
+
+	for _, tt := range []struct {
+		p    Parser
+		want []string
+	}{
+		{Parser{Merchants: merchants}, []string{"СІЛЬПО KYIV", "АТБ", "google *youtube", "Від: ІВАН ПЕТРЕНКО"}},
+		{Parser{Merchants: merchants, TitleCase: true}, []string{"Сільпо Kyiv", "АТБ", "Google *Youtube", "Від: Іван Петренко"}},
+	} {
+		records, err := tt.p.ReadCSV(strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, rec := range records {
+			if rec.Title != tt.want[i] {
+				t.Errorf("title case %v: title %d is %q, expected %q", tt.p.TitleCase, i, rec.Title, tt.want[i])
+			}
+		}
+	}
+}
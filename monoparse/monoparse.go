@@ -0,0 +1,699 @@
+/*
+Package monoparse - parsing CSV statements exported from monobank
+
+	f, err := os.Open("mono.csv")
+	...
+	records, err := monoparse.ReadCSV(f)
+*/
+package monoparse
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+const (
+	CentsCoef  = 100
+	RateCoef   = 100_000
+	DateFormat = "02.01.2006 15:04:05"
+	utf8BOM    = "\xEF\xBB\xBF"
+
+	maxBannerRows = 20       // rows before CSV header which are skipped (account name, period, card number)
+	detectSize    = 64 << 10 // bytes of file start for detecting delimiter, for banner and header
+)
+
+// Record - one operation from statement
+type Record struct {
+	CreatedAt  time.Time     `db:"created_at"`
+	Title      string        `db:"title"`
+	MCC        int           `db:"mcc"`
+	Amount     int           `db:"amount"`      // in UAH * 100 (kopecks)
+	AmountOrig int           `db:"amount_orig"` // in original currency (USD/EUR): V * 100 (cents), same sign as Amount
+	Currency   string        `db:"currency"`    // UAH/USD/EUR
+	Exchange   sql.NullInt64 `db:"exchange"`    // exchange rate: V * 100000, NULL for operations without conversion
+	Commission int           `db:"commission"`  // in UAH * 100
+	Cashback   int           `db:"cashback"`    // in UAH * 100
+	Rest       int           `db:"rest"`        // in UAH * 100
+	Category   string        `db:"category"`    // by MCC, see mcc.go
+	SourceFile string        `db:"source_file"` // CSV file name, not from CSV data
+	RawAmount  string        `db:"raw_amount"`  // original amount string from CSV
+	Account    string        `db:"account"`     // account/card name, not from CSV data
+	Hash       string        `db:"hash"`        // see ContentHash
+}
+
+// ContentHash - SHA-256 of all operation fields and account,
+// derived and provenance fields (Category, SourceFile, RawAmount) are not included
+func (r Record) ContentHash() string {
+	fields := []string{
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		r.Title,
+		strconv.Itoa(r.MCC),
+		strconv.Itoa(r.Amount),
+		strconv.Itoa(r.AmountOrig),
+		r.Currency,
+		strconv.FormatInt(r.Exchange.Int64, 10), // 0 for NULL, as before NULL was introduced
+		strconv.Itoa(r.Commission),
+		strconv.Itoa(r.Cashback),
+		strconv.Itoa(r.Rest),
+		r.Account,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Parser - settings for parsing CSV statement, zero value is for the default monobank format
+type Parser struct {
+	Comma rune // fields delimiter, ',' if zero
+	// DetectComma - delimiter is detected by header among ',', ';' and tab, Comma is used if header is not found
+	// (e.g. for files without header)
+	DetectComma bool
+	Location    *time.Location // time zone of operation time, UTC if nil
+	// Warn - called for suspicious but parsed records, i - record index (without header)
+	Warn func(i int, msg string)
+	// Skip - called for rows shorter than header, which are skipped
+	Skip func(i int, row []string)
+	// Error - called for rows which can't be parsed with *RecordError, such rows are skipped,
+	// if nil, reading stops on the first error
+	Error func(i int, err error)
+	// Strict - error for rows shorter than header instead of skipping them, for invalid currency codes
+	// instead of warning and for amounts in other notation than decimal (ErrNumberNotation) instead of skipping with warning
+	Strict bool
+	// Type - statement type, card statement by default
+	Type StatementType
+	// DateFormat - Go layout of operation time, layout of Profile if empty,
+	// fractional seconds are accepted without layout for them
+	DateFormat string
+	// Profile - statement format: units of amounts, time layout, header names, MonobankProfile if nil
+	Profile *Profile
+	// NoHeader - file has no header, the first row is a record, columns are in DefaultColumns order,
+	// for card statements only. Without it the first row which looks like a record (starts with time)
+	// instead of header is read as a record too, with a warning
+	NoHeader bool
+	// SkipLines - number of lines before header to skip, without it up to 20 rows before header
+	// (bank info banner) are skipped if the first row is not a valid header
+	SkipLines int
+	// StrictFields - error of CSV reader (with line number) for rows with other number of fields than header,
+	// by default such rows are parsed if they are long enough, see Strict
+	StrictFields bool
+	// StripTitle - matches are removed from Title (after normalization of whitespace), e.g. card masks,
+	// Title is a part of dedup key and ContentHash, so the same pattern should be used for a table
+	StripTitle *regexp.Regexp
+	// Merchants - titles are replaced by canonical merchant names (after StripTitle), it changes dedup key too
+	Merchants MerchantMap
+	// TitleCase - titles which aren't in Merchants are title-cased: "СІЛЬПО KYIV" -> "Сільпо Kyiv",
+	// it changes dedup key too, names of Merchants are kept as is (e.g. for acronyms)
+	TitleCase bool
+	// SkipChecks - checks of suspicious values which are not run
+	SkipChecks map[Check]bool
+	// Anomaly - called instead of Warn for records which fail a check, e.g. for counting them by check
+	Anomaly func(i int, check Check, msg string)
+}
+
+// Check - check of suspicious values of parsed records, see Parser.SkipChecks
+type Check string
+
+const (
+	CheckCurrency Check = "currency" // ISO 4217 code, amounts of UAH operations, exchange rate of foreign ones
+	CheckMCC      Check = "mcc"      // 3-4 digit code
+)
+
+// profile - statement profile with DateFormat applied
+func (p Parser) profile() Profile {
+	prof := MonobankProfile
+	if p.Profile != nil {
+		prof = *p.Profile
+	}
+	if p.DateFormat != "" {
+		prof.DateFormat = p.DateFormat
+	}
+
+	return prof
+}
+
+// ParseHeader - find columns by header names of profile for statement type
+func (p Parser) ParseHeader(header []string) (Columns, error) {
+	return p.profile().ParseHeader(p.Type, header)
+}
+
+// ReadCSV - read all records from CSV statement with header, using default settings
+func ReadCSV(r io.Reader) ([]Record, error) {
+	return Parser{}.ReadCSV(r)
+}
+
+// ReadCSV - read all records from CSV statement with header,
+// rows shorter than header are skipped or are errors in strict mode
+func (p Parser) ReadCSV(r io.Reader) ([]Record, error) {
+	result := []Record{}
+	err := p.ReadEach(r, func(rec Record) error {
+		result = append(result, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReadEach - read CSV statement with header row by row and call fn for each record,
+// records are not collected, so memory doesn't depend on file size, error of fn stops reading
+func (p Parser) ReadEach(r io.Reader, fn func(Record) error) error {
+	// skip UTF-8 BOM, files re-saved by Excel have it
+	br := bufio.NewReaderSize(r, detectSize)
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		if _, err := br.Discard(len(utf8BOM)); err != nil {
+			return err
+		}
+	}
+	skipped := 0
+	for ; skipped < p.SkipLines; skipped++ {
+		if _, err := br.ReadString('\n'); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if p.DetectComma {
+		p.Comma = p.detectComma(br)
+	}
+
+	csvr := csv.NewReader(br)
+	csvr.FieldsPerRecord = -1 // variable number of fields
+	csvr.ReuseRecord = true   // fields are copied by parsing
+	if p.Comma != 0 {
+		csvr.Comma = p.Comma
+	}
+
+	header, err := csvr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return csvLineError(err, skipped)
+	}
+	header = append([]string{}, header...)
+
+	// line of the last read row in file, for errors
+	line := func() int {
+		n, _ := csvr.FieldPos(0)
+		return n + skipped
+	}
+
+	var rows *rowParser
+	parse := func(row []string) error {
+		rec, ok, err := rows.parse(row, line())
+		if err != nil || !ok {
+			return err
+		}
+		return fn(rec)
+	}
+
+	if p.noHeader(header) {
+		if rows, err = p.headerlessRowParser(); err != nil {
+			return err
+		}
+		if err := parse(header); err != nil {
+			return err
+		}
+	} else if header, err = p.skipBanner(csvr, header); err != nil {
+		return csvLineError(err, skipped)
+	}
+	if p.StrictFields {
+		csvr.FieldsPerRecord = len(header)
+	}
+
+	for {
+		row, err := csvr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return csvLineError(err, skipped)
+		}
+
+		// header is parsed with the first record, so file with header only is empty and valid
+		if rows == nil {
+			if rows, err = p.newRowParser(header); err != nil {
+				return err
+			}
+		}
+
+		if err := parse(row); err != nil {
+			return err
+		}
+	}
+}
+
+// detectComma - delimiter with which header is found in rows at start of file (after banner rows),
+// Comma if it is not found, file is not consumed
+func (p Parser) detectComma(br *bufio.Reader) rune {
+	// error is for files shorter than detectSize
+	start, _ := br.Peek(detectSize)
+	for _, comma := range []rune{',', ';', '\t'} {
+		csvr := csv.NewReader(bytes.NewReader(start))
+		csvr.Comma = comma
+		csvr.FieldsPerRecord = -1
+		csvr.LazyQuotes = true
+		for n := 0; n <= maxBannerRows; n++ {
+			row, err := csvr.Read()
+			if err != nil {
+				break
+			}
+			if _, err := p.ParseHeader(row); err == nil {
+				return comma
+			}
+		}
+	}
+
+	return p.Comma
+}
+
+// csvLineError - fix line numbers of CSV reader error by number of lines skipped before reading,
+// so they are line numbers in file
+func csvLineError(err error, skipped int) error {
+	var parseErr *csv.ParseError
+	if skipped > 0 && errors.As(err, &parseErr) {
+		parseErr.StartLine += skipped
+		parseErr.Line += skipped
+	}
+
+	return err
+}
+
+// skipBanner - skip rows before header, returns header or the first row if the file has only one row,
+// error of the first row as a header is returned if header is not found in maxBannerRows rows
+func (p Parser) skipBanner(csvr *csv.Reader, first []string) ([]string, error) {
+	_, headerErr := p.ParseHeader(first)
+	if headerErr == nil {
+		return first, nil
+	}
+
+	for n := 0; n < maxBannerRows; n++ {
+		row, err := csvr.Read()
+		if err == io.EOF && n == 0 {
+			// file with header only is empty and valid
+			return first, nil
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.ParseHeader(row); err == nil {
+			return append([]string{}, row...), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Error parsing CSV header: %w", headerErr)
+}
+
+// ReadRows - parse rows of statement with header from other sources than CSV (e.g. spreadsheets)
+func (p Parser) ReadRows(data [][]string) ([]Record, error) {
+	return p.ReadRowsFrom(data, 1)
+}
+
+// ReadRowsFrom - same as ReadRows, firstLine is the line (spreadsheet row) of data[0] in source for errors
+func (p Parser) ReadRowsFrom(data [][]string, firstLine int) ([]Record, error) {
+	if len(data) == 0 {
+		return []Record{}, nil
+	}
+
+	var (
+		rows *rowParser
+		err  error
+	)
+	if p.noHeader(data[0]) {
+		rows, err = p.headerlessRowParser()
+	} else {
+		rows, err = p.newRowParser(data[0])
+		data, firstLine = data[1:], firstLine+1
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Record, 0, len(data))
+	for n, row := range data {
+		rec, ok, err := rows.parse(row, firstLine+n)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, rec)
+		}
+	}
+
+	return result, nil
+}
+
+// rowParser - parser of rows after header
+type rowParser struct {
+	p      Parser
+	prof   Profile
+	cols   Columns
+	recLen int
+	i      int          // record index (without header)
+	caser  *cases.Caser // for Parser.TitleCase, it has state, so it isn't shared by parsers
+}
+
+func (p Parser) newRowParser(header []string) (*rowParser, error) {
+	cols, err := p.ParseHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing CSV header: %w", err)
+	}
+
+	return &rowParser{p: p, prof: p.profile(), cols: cols, recLen: len(header)}, nil
+}
+
+// headerlessRowParser - parser of rows in DefaultColumns order, for files without header
+func (p Parser) headerlessRowParser() (*rowParser, error) {
+	if p.Type != CardStatement {
+		return nil, fmt.Errorf("Files without header are supported only for card statements")
+	}
+
+	return &rowParser{p: p, prof: p.profile(), cols: DefaultColumns, recLen: len(DefaultColumns)}, nil
+}
+
+// noHeader - file has no header: it's set by NoHeader or the first row looks like a record
+// (it isn't a valid header and starts with time), the latter is reported by Warn
+func (p Parser) noHeader(first []string) bool {
+	if p.NoHeader {
+		return true
+	}
+	if p.Type != CardStatement || len(first) == 0 {
+		return false
+	}
+	if _, err := p.ParseHeader(first); err == nil {
+		return false
+	}
+
+	if _, err := time.Parse(p.profile().DateFormat, first[0]); err != nil {
+		return false
+	}
+
+	p.warn(0, "The first row looks like a record instead of header, it's read as a record, columns are in default order")
+	return true
+}
+
+// RecordError - error of parsing record with its position in source
+type RecordError struct {
+	Record int // record index (without header)
+	Line   int // 1-based line in file (row in spreadsheet), header and skipped lines are counted
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("Error parsing record %d (line %d): %s", e.Record, e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// parse - parse next row at line, ok is false for skipped rows, errors are *RecordError
+func (rp *rowParser) parse(row []string, line int) (rec Record, ok bool, err error) {
+	i := rp.i
+	rp.i++
+
+	if len(row) < rp.recLen {
+		if rp.p.Strict {
+			return rec, false, &RecordError{Record: i, Line: line, Err: fmt.Errorf("Record is shorter than header (%d of %d fields): %q", len(row), rp.recLen, row)}
+		}
+		if rp.p.Skip != nil {
+			rp.p.Skip(i, append([]string{}, row...))
+		}
+		return rec, false, nil
+	}
+
+	rec, err = rp.cols.parseRecord(row, rp.p.Location, rp.prof)
+	if errors.Is(err, ErrNumberNotation) && !rp.p.Strict {
+		rp.p.warn(i, fmt.Sprintf("Record is skipped: %s", err))
+		return rec, false, nil
+	}
+	if err == nil && rp.p.StripTitle != nil {
+		if title := normalizeTitle(rp.p.StripTitle.ReplaceAllString(rec.Title, "")); title != "" {
+			rec.Title = title
+		} else {
+			rp.p.warn(i, fmt.Sprintf("Title %q is empty after removing matches of title pattern, it's kept", rec.Title))
+		}
+	}
+	if name, ok := rp.p.Merchants.Name(rec.Title); err == nil && ok {
+		rec.Title = name
+	} else if err == nil && rp.p.TitleCase {
+		rec.Title = rp.titleCase(rec.Title)
+	}
+	if err == nil {
+		err = rp.p.validate(i, row, rp.cols, rec)
+	}
+	if err != nil {
+		err = &RecordError{Record: i, Line: line, Err: err}
+		if rp.p.Error != nil {
+			rp.p.Error(i, err)
+			return rec, false, nil
+		}
+		return rec, false, err
+	}
+
+	return rec, true, nil
+}
+
+// titleCase - title in title case, by rules of Ukrainian, they cover Latin letters too
+func (rp *rowParser) titleCase(title string) string {
+	if rp.caser == nil {
+		caser := cases.Title(language.Ukrainian)
+		rp.caser = &caser
+	}
+
+	return rp.caser.String(title)
+}
+
+// validate - report suspicious values, usually caused by shifted columns or a different statement layout,
+// invalid currency is an error in strict mode
+func (p Parser) validate(i int, row []string, cols Columns, rec Record) error {
+	if currency := cols.value(row, colCurrency); !p.SkipChecks[CheckCurrency] && !ValidCurrency(currency) {
+		msg := fmt.Sprintf("Currency is not a 3-letter ISO 4217 code: %q", currency)
+		if p.Strict {
+			return errors.New(msg)
+		}
+		p.anomaly(i, CheckCurrency, msg)
+	}
+
+	if mcc := cols.value(row, colMCC); !p.SkipChecks[CheckMCC] && !ValidMCC(mcc) {
+		p.anomaly(i, CheckMCC, fmt.Sprintf("MCC is not a 3-4 digit code: %q", mcc))
+	}
+
+	if msg := checkCurrencyAmounts(rec); !p.SkipChecks[CheckCurrency] && msg != "" {
+		p.anomaly(i, CheckCurrency, msg)
+	}
+
+	return nil
+}
+
+// checkCurrencyAmounts - check invariants of amounts in card (UAH) and operation currencies:
+// for UAH operation they are equal, foreign currency operation has exchange rate,
+// returns description of violated invariant or empty string, records without currency (jar) are not checked
+func checkCurrencyAmounts(rec Record) string {
+	switch rec.Currency {
+	case "":
+		return ""
+	case "UAH":
+		if rec.AmountOrig != rec.Amount {
+			return fmt.Sprintf("Amount in UAH operation differs from AmountOrig: %d != %d", rec.Amount, rec.AmountOrig)
+		}
+	default:
+		if !rec.Exchange.Valid && rec.AmountOrig != 0 {
+			return fmt.Sprintf("Exchange rate is missing for %s operation", rec.Currency)
+		}
+	}
+
+	return ""
+}
+
+func (p Parser) warn(i int, msg string) {
+	if p.Warn != nil {
+		p.Warn(i, msg)
+	}
+}
+
+func (p Parser) anomaly(i int, check Check, msg string) {
+	if p.Anomaly != nil {
+		p.Anomaly(i, check, msg)
+		return
+	}
+	p.warn(i, msg)
+}
+
+// ParseRecord - parse CSV row with default columns order
+func ParseRecord(row []string) (Record, error) {
+	return DefaultColumns.ParseRecord(row)
+}
+
+// ParseRecord - parse CSV row with columns found by header, time is in UTC
+func (cols Columns) ParseRecord(row []string) (Record, error) {
+	return cols.parseRecord(row, time.UTC, MonobankProfile)
+}
+
+func (cols Columns) parseRecord(row []string, loc *time.Location, prof Profile) (Record, error) {
+	// CSV header:
+	// "Дата i час операції","Деталі операції",MCC,"Сума в валюті картки (UAH)","Сума в валюті операції",Валюта,Курс,"Сума комісій (UAH)","Сума кешбеку (UAH)","Залишок після операції"
+	// columns are found by header names of profile, see profile.go
+
+	r := Record{}
+	for _, i := range cols {
+		if i >= len(row) {
+			return r, fmt.Errorf("Row is too short: %d fields", len(row))
+		}
+	}
+
+	// parse CreatedAt
+	if loc == nil {
+		loc = time.UTC
+	}
+	createdAt, err := time.ParseInLocation(prof.DateFormat, cols.value(row, colCreatedAt), loc)
+	if err != nil {
+		return r, fmt.Errorf("Error parsing CreatedAt %q, expected layout %q: %w", cols.value(row, colCreatedAt), prof.DateFormat, err)
+	}
+	r.CreatedAt = createdAt
+
+	// parse Title, whitespace differs in exports of the same operation, it's a part of dedup key
+	r.Title = normalizeTitle(cols.value(row, colTitle))
+
+	// parse MCC
+	if r.MCC, err = ParseAsInt(cols.value(row, colMCC), 1); err != nil {
+		return r, fmt.Errorf("Error parsing MCC: %w", err)
+	}
+	r.Category = mccCategory(r.MCC)
+
+	// parse Amount
+	if r.Amount, err = prof.parseAmount(cols.value(row, colAmount)); err != nil {
+		return r, fmt.Errorf("Error parsing Amount: %w", err)
+	}
+	r.RawAmount = cols.value(row, colAmount)
+
+	// parse AmountOrig
+	if r.AmountOrig, err = prof.parseAmount(cols.value(row, colAmountOrig)); err != nil {
+		return r, fmt.Errorf("Error parsing AmountOrig: %w", err)
+	}
+
+	// sign convention: amounts in card and operation currencies are negative for outgoing operations,
+	// Amount is consistent with balance (Rest), some exports have AmountOrig with opposite sign
+	if r.Amount != 0 && r.AmountOrig != 0 && (r.Amount < 0) != (r.AmountOrig < 0) {
+		r.AmountOrig = -r.AmountOrig
+	}
+
+	// parse Currency
+	r.Currency = cols.value(row, colCurrency)
+
+	// parse Exchange
+	if r.Exchange, err = prof.parseRate(cols.value(row, colExchange)); err != nil {
+		return r, fmt.Errorf("Error parsing Exchange: %w", err)
+	}
+
+	// parse Commission
+	if r.Commission, err = prof.parseAmount(cols.value(row, colCommission)); err != nil {
+		return r, fmt.Errorf("Error parsing Commission: %w", err)
+	}
+
+	// parse Cashback
+	if r.Cashback, err = prof.parseAmount(cols.value(row, colCashback)); err != nil {
+		return r, fmt.Errorf("Error parsing Cashback: %w", err)
+	}
+
+	// parse Rest
+	if r.Rest, err = prof.parseAmount(cols.value(row, colRest)); err != nil {
+		return r, fmt.Errorf("Error parsing Rest: %w", err)
+	}
+
+	return r, nil
+}
+
+// ErrNumberNotation - number is not in decimal notation, e.g. scientific "1e3" or "Inf" of a buggy export
+var ErrNumberNotation = errors.New("Number is not in decimal notation")
+
+var decimalRe = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// ParseAsInt - parse decimal string as integer value * coef, "—" and empty string are 0, "-0" is 0,
+// other notations accepted by strconv.ParseFloat (1e3, Inf, 0x1p3), several separators ("1.234,56", "1,234.56")
+// and more fractional digits than coef has ("1,234" or "0.125" for kopecks) are ErrNumberNotation errors
+func ParseAsInt(s string, coef int) (int, error) {
+	if s == "—" || s == "-" || s == "" {
+		return 0, nil
+	}
+
+	n, ok := normalizeNumber(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q, decimal and thousands separators are ambiguous", ErrNumberNotation, s)
+	}
+	if !decimalRe.MatchString(n) {
+		if _, err := strconv.ParseFloat(n, 64); err == nil {
+			return 0, fmt.Errorf("%w: %q", ErrNumberNotation, s)
+		}
+	} else if _, frac, _ := strings.Cut(n, "."); len(frac) > len(strconv.Itoa(coef))-1 {
+		// rounding would change the value, e.g. "1,234" with comma as thousands separator would be 1.23
+		return 0, fmt.Errorf("%w: %q, more than %d fractional digits", ErrNumberNotation, s, len(strconv.Itoa(coef))-1)
+	}
+
+	v, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Error parsing %s to float: %w", s, err)
+	}
+
+	// round, because of float representation 19.99 * 100 can be 1998.9999
+	return int(math.Round(v * float64(coef))), nil
+}
+
+// ParseAsNullInt - same as ParseAsInt, but "—" and empty string are NULL (not valid) value
+func ParseAsNullInt(s string, coef int) (sql.NullInt64, error) {
+	if s == "—" || s == "-" || s == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	v, err := ParseAsInt(s, coef)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	return sql.NullInt64{Int64: int64(v), Valid: true}, nil
+}
+
+// normalizeTitle - collapse runs of whitespace (including non-breaking spaces) to one space and trim
+func normalizeTitle(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// normalizeNumber - remove spaces of thousands separators and use dot as decimal separator: "1 234,56" -> "1234.56",
+// ok is false for several separators: "1.234,56", "1,234.56", "1,234,567", they are thousands separators
+// in one locale and decimal ones in another
+func normalizeNumber(s string) (string, bool) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) { // including non-breaking spaces
+			return -1
+		}
+		return r
+	}, s)
+
+	if strings.Count(s, ".")+strings.Count(s, ",") > 1 {
+		return s, false
+	}
+
+	return strings.Replace(s, ",", ".", 1), true
+}
@@ -0,0 +1,451 @@
+package monoparse
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFixture - read all records of file in testdata by parser
+func readFixture(t *testing.T, p Parser, name string) []Record {
+	t.Helper()
+
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := p.ReadCSV(f)
+	if err != nil {
+		t.Fatalf("ReadCSV(%s): %s", name, err)
+	}
+
+	return records
+}
+
+func TestReadCSVWithBOM(t *testing.T) {
+	records := readFixture(t, Parser{}, "bom.csv")
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	if want := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC); !records[0].CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt of the first record: expected %s, got %s", want, records[0].CreatedAt)
+	}
+	if records[0].Title != "АТБ" || records[0].Amount != -12050 {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+}
+
+// BOM of file re-saved by Excel in input of ReadEach, it's before header or before banner line
+func TestReadEachBOM(t *testing.T) {
+	plain, err := os.ReadFile("testdata/statement.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Record{}
+	if err := (Parser{}).ReadEach(bytes.NewReader(plain), func(rec Record) error {
+		want = append(want, rec)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != 3 {
+		t.Fatalf("expected 3 records of plain file, got %d", len(want))
+	}
+
+	tests := []struct {
+		name  string
+		p     Parser
+		input string
+	}{
+		{"BOM", Parser{}, "\xEF\xBB\xBF" + string(plain)},
+		{"BOM with detected delimiter", Parser{DetectComma: true}, "\xEF\xBB\xBF" + string(plain)},
+		{"BOM before skipped line", Parser{SkipLines: 1}, "\xEF\xBB\xBFВиписка з 01.02.2024\n" + string(plain)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []Record{}
+			if err := tt.p.ReadEach(strings.NewReader(tt.input), func(rec Record) error {
+				got = append(got, rec)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("records differ from file without BOM:\n%+v\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseAsIntSeparators(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"1 234,56", 123456},
+		{"1\u00a0234,56", 123456}, // non-breaking space
+		{"-1 234,56", -123456},
+		{"1234.56", 123456},
+		{"1234,5", 123450},
+		{"0,07", 7},
+		{"—", 0},
+		{"-", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseAsInt(tt.in, CentsCoef)
+		if err != nil {
+			t.Errorf("ParseAsInt(%q): unexpected error: %s", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAsInt(%q) = %d, expected %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseAsIntAmbiguousSeparators(t *testing.T) {
+	for _, in := range []string{
+		"1.234,56",  // European thousands separator
+		"1,234.56",  // English thousands separator
+		"1,234,567", // several thousands separators
+		"1.234.567",
+		"1,234", // thousands separator or 3 fractional digits
+		"0.125",
+		"-19.999",
+	} {
+		if got, err := ParseAsInt(in, CentsCoef); !errors.Is(err, ErrNumberNotation) {
+			t.Errorf("ParseAsInt(%q) = %d, %v, expected ErrNumberNotation", in, got, err)
+		}
+	}
+
+	// precision of exchange rates is 5 digits
+	if got, err := ParseAsInt("41.12345", RateCoef); err != nil || got != 4112345 {
+		t.Errorf("ParseAsInt of rate = %d, %v, expected 4112345", got, err)
+	}
+	if _, err := ParseAsInt("41.123456", RateCoef); !errors.Is(err, ErrNumberNotation) {
+		t.Errorf("ParseAsInt of rate with 6 fractional digits: expected ErrNumberNotation, got %v", err)
+	}
+}
+
+// amounts which are off by one with truncation of float value * 100
+func TestParseAsIntRounding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"19.99", 1999},
+		{"0.07", 7},
+		{"-0.01", -1},
+		{"4.35", 435},
+		{"1.15", 115},
+		{"-8.7", -870},
+		{"0.29", 29},
+		{"-120.50", -12050},
+	}
+	for _, tt := range tests {
+		if got, err := ParseAsInt(tt.in, CentsCoef); err != nil || got != tt.want {
+			t.Errorf("ParseAsInt(%q) = %d, %v, expected %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// the same amounts in columns of record, amounts, commission and cashback are parsed by ParseAsInt
+func TestParseRecordRounding(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want int
+	}{
+		{"19.99", 1999},
+		{"4.35", 435},
+		{"1.15", 115},
+		{"-8.7", -870},
+		{"0.29", 29},
+		{"0.07", 7},
+		{"-0.01", -1},
+	} {
+		rec, err := ParseRecord([]string{"01.02.2024 10:00:00", "АТБ", "5411", tt.in, tt.in, "UAH", "—", tt.in, tt.in, tt.in})
+		if err != nil {
+			t.Errorf("ParseRecord with %q: %s", tt.in, err)
+			continue
+		}
+		if rec.Amount != tt.want || rec.AmountOrig != tt.want || rec.Commission != tt.want || rec.Cashback != tt.want || rec.Rest != tt.want {
+			t.Errorf("ParseRecord with %q: %+v, expected %d in amounts", tt.in, rec, tt.want)
+		}
+	}
+}
+
+func TestDetectComma(t *testing.T) {
+	want := readFixture(t, Parser{}, "statement.csv")
+	for i := range want {
+		want[i].RawAmount = ""
+	}
+
+	for _, tt := range []struct {
+		name string
+		p    Parser
+	}{
+		{"statement.csv", Parser{DetectComma: true}},
+		{"statement_semicolon.csv", Parser{DetectComma: true}},
+		{"statement_semicolon.csv", Parser{Comma: ';'}},
+		// explicit Comma is a fallback for files without found header only
+		{"statement_semicolon.csv", Parser{DetectComma: true, Comma: '\t'}},
+	} {
+		got := readFixture(t, tt.p, tt.name)
+		for i := range got {
+			got[i].RawAmount = "" // decimal comma in semicolon file
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s with comma %q (detect: %v): records differ from comma-delimited file:\n%+v\n%+v", tt.name, tt.p.Comma, tt.p.DetectComma, got, want)
+		}
+	}
+}
+
+func TestDetectCommaWithoutHeader(t *testing.T) {
+	detect := func(p Parser, data string) rune {
+		return p.detectComma(bufio.NewReaderSize(strings.NewReader(data), detectSize))
+	}
+
+	if comma := detect(Parser{Comma: ';'}, "a;b;c\n1;2;3\n"); comma != ';' {
+		t.Errorf("expected fallback to Comma, got %q", comma)
+	}
+	if comma := detect(Parser{}, "a,b\n"); comma != 0 {
+		t.Errorf("expected zero Comma (default), got %q", comma)
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []string
+		want Record
+	}{
+		{
+			name: "UAH",
+			row:  []string{"01.02.2024 10:00:00", "АТБ", "5411", "-120.50", "-120.50", "UAH", "", "0.00", "0.00", "1000.00"},
+			want: Record{
+				CreatedAt: time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC),
+				Title:     "АТБ", MCC: 5411, Amount: -12050, AmountOrig: -12050, Currency: "UAH",
+				Rest: 100000, Category: mccCategory(5411), RawAmount: "-120.50",
+			},
+		},
+		{
+			name: "USD with exchange rate",
+			row:  []string{"01.02.2024 12:30:00", "Google", "5818", "-41.10", "-1.00", "USD", "41.1000", "0.50", "0.00", "958.90"},
+			want: Record{
+				CreatedAt: time.Date(2024, time.February, 1, 12, 30, 0, 0, time.UTC),
+				Title:     "Google", MCC: 5818, Amount: -4110, AmountOrig: -100, Currency: "USD",
+				Exchange:   sql.NullInt64{Int64: 4110000, Valid: true},
+				Commission: 50, Rest: 95890, Category: mccCategory(5818), RawAmount: "-41.10",
+			},
+		},
+		{
+			name: "placeholders",
+			row:  []string{"02.02.2024 09:00:00", "Від: Іван", "4829", "500.00", "500.00", "UAH", "—", "—", "—", "1458.90"},
+			want: Record{
+				CreatedAt: time.Date(2024, time.February, 2, 9, 0, 0, 0, time.UTC),
+				Title:     "Від: Іван", MCC: 4829, Amount: 50000, AmountOrig: 50000, Currency: "UAH",
+				Rest: 145890, Category: mccCategory(4829), RawAmount: "500.00",
+			},
+		},
+		{
+			name: "cashback",
+			row:  []string{"03.02.2024 18:15:00", "Сільпо", "5411", "-1 250,00", "-1 250,00", "UAH", "—", "—", "12,50", "208,90"},
+			want: Record{
+				CreatedAt: time.Date(2024, time.February, 3, 18, 15, 0, 0, time.UTC),
+				Title:     "Сільпо", MCC: 5411, Amount: -125000, AmountOrig: -125000, Currency: "UAH",
+				Cashback: 1250, Rest: 20890, Category: mccCategory(5411), RawAmount: "-1 250,00",
+			},
+		},
+	}
+
+	// columns found by header of real monobank export, as rows are parsed by ReadEach
+	f, err := os.Open("testdata/statement.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	header, err := csv.NewReader(f).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols, err := Parser{}.ParseHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecord(tt.row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRecord:\n got %+v\nwant %+v", got, tt.want)
+			}
+
+			got, err = cols.ParseRecord(tt.row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Columns.ParseRecord:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecordErrors(t *testing.T) {
+	valid := []string{"01.02.2024 10:00:00", "АТБ", "5411", "-120.50", "-120.50", "UAH", "—", "—", "—", "1000.00"}
+	for i, bad := range map[int]string{0: "2024-02-01 10:00", 2: "MCC", 3: "abc", 6: "1e3", 9: "1.000,00"} {
+		row := append([]string{}, valid...)
+		row[i] = bad
+		if _, err := ParseRecord(row); err == nil {
+			t.Errorf("ParseRecord with %q in column %d: expected error", bad, i)
+		}
+	}
+	if _, err := ParseRecord(valid[:5]); err == nil {
+		t.Error("ParseRecord of short row: expected error")
+	}
+}
+
+func TestParseRecordTitleWhitespace(t *testing.T) {
+	row := func(title string) []string {
+		return []string{"01.02.2024 10:00:00", title, "5411", "-120.50", "-120.50", "UAH", "—", "—", "—", "1000.00"}
+	}
+	want, err := ParseRecord(row("Сільпо Київ"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, title := range []string{"Сільпо  Київ", " Сільпо Київ ", "Сільпо\u00a0Київ", "Сільпо \t Київ\u00a0"} {
+		got, err := ParseRecord(row(title))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// dedup key: operation time, title and amount, or ContentHash
+		if got.Title != want.Title || !got.CreatedAt.Equal(want.CreatedAt) || got.Amount != want.Amount {
+			t.Errorf("title %q: dedup key differs: %q, expected %q", title, got.Title, want.Title)
+		}
+		if got.ContentHash() != want.ContentHash() {
+			t.Errorf("title %q: ContentHash differs", title)
+		}
+	}
+}
+
+func TestParseRecordAmountSigns(t *testing.T) {
+	tests := []struct {
+		amount, amountOrig string
+		want, wantOrig     int
+	}{
+		{"-41.10", "-1.00", -4110, -100},
+		{"-41.10", "1.00", -4110, -100}, // export with opposite sign of amount in operation currency
+		{"41.10", "-1.00", 4110, 100},   // refund
+		{"41.10", "1.00", 4110, 100},
+		{"0.00", "-1.00", 0, -100}, // sign is unknown without amount in card currency
+	}
+	for _, tt := range tests {
+		row := []string{"01.02.2024 12:30:00", "Google", "5818", tt.amount, tt.amountOrig, "USD", "41.1000", "—", "—", "958.90"}
+		rec, err := ParseRecord(row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Amount != tt.want || rec.AmountOrig != tt.wantOrig {
+			t.Errorf("amounts %s, %s: parsed as %d, %d, expected %d, %d", tt.amount, tt.amountOrig, rec.Amount, rec.AmountOrig, tt.want, tt.wantOrig)
+		}
+		if rec.Exchange.Int64 <= 0 {
+			t.Errorf("amounts %s, %s: exchange rate is not positive: %d", tt.amount, tt.amountOrig, rec.Exchange.Int64)
+		}
+	}
+}
+
+func TestReadCSVNumberNotation(t *testing.T) {
+	warnings := []int{}
+	p := Parser{Warn: func(i int, msg string) {
+		if strings.Contains(msg, ErrNumberNotation.Error()) {
+			warnings = append(warnings, i)
+		}
+	}}
+	records := readFixture(t, p, "number_notation.csv")
+
+	// "-0" is 0, record with "1e3" is skipped with warning
+	if len(records) != 2 || records[0].Title != "Shop A" || records[1].Title != "Shop C" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if records[0].Amount != 0 || records[0].AmountOrig != 0 || records[1].Amount != -1000 {
+		t.Errorf("unexpected amounts: %+v", records)
+	}
+	if !reflect.DeepEqual(warnings, []int{1}) {
+		t.Errorf("expected notation warning for record 1, got %v", warnings)
+	}
+
+	// error in strict mode
+	f, err := os.Open("testdata/number_notation.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := (Parser{Strict: true}).ReadCSV(f); !errors.Is(err, ErrNumberNotation) {
+		t.Errorf("strict mode: expected ErrNumberNotation, got %v", err)
+	}
+}
+
+func TestParseAsIntNotation(t *testing.T) {
+	for _, in := range []string{"1e3", "1E3", "-1e-2", "Inf", "-Inf", "+Inf", "NaN", "infinity", "0x1p3"} {
+		if got, err := ParseAsInt(in, CentsCoef); !errors.Is(err, ErrNumberNotation) {
+			t.Errorf("ParseAsInt(%q) = %d, %v, expected ErrNumberNotation", in, got, err)
+		}
+	}
+
+	for _, in := range []string{"-0", "-0.00", "+0", "0"} {
+		if got, err := ParseAsInt(in, CentsCoef); err != nil || got != 0 {
+			t.Errorf("ParseAsInt(%q) = %d, %v, expected 0", in, got, err)
+		}
+	}
+}
+
+// benchRows - rows of CSV statement generated for BenchmarkReadEach
+const benchRows = 100_000
+
+func BenchmarkReadEach(b *testing.B) {
+	header, err := os.ReadFile("testdata/statement.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	buf.Write(header[:bytes.IndexByte(header, '\n')+1])
+	start := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < benchRows; i++ {
+		fmt.Fprintf(buf, "\"%s\",\"Shop %d\",5411,-%d.%02d,-%d.%02d,UAH,—,—,1.20,1000.00\n",
+			start.Add(time.Duration(i)*time.Minute).Format(DateFormat), i%100, 1+i%500, i%100, 1+i%500, i%100)
+	}
+	data := buf.Bytes()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		err := Parser{}.ReadEach(bytes.NewReader(data), func(Record) error {
+			n++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n != benchRows {
+			b.Fatalf("expected %d records, got %d", benchRows, n)
+		}
+	}
+}
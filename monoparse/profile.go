@@ -0,0 +1,94 @@
+package monoparse
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile - format of a statement variant: units of amounts and rates, layout of operation time and header names,
+// Record values are always in the same units (kopecks, rate * 100000) regardless of profile
+type Profile struct {
+	Name       string
+	CentsCoef  int    // multiplier of amounts in statement to Record amounts (kopecks), 100 for decimal amounts
+	RateCoef   int    // multiplier of exchange rates in statement to Record.Exchange (rate * 100000)
+	DateFormat string // Go layout of operation time, fractional seconds are accepted without layout for them
+	// Headers - header names by Record field name: CreatedAt, Title, MCC, Amount, AmountOrig, Currency,
+	// Exchange, Commission, Cashback, Rest, a column may have several names,
+	// card currency suffix like " (UAH)" is stripped before matching
+	Headers map[string][]string
+}
+
+// columnFields - Record field name of each column, keys of Profile.Headers
+var columnFields = [columnsCount]string{
+	colCreatedAt:  "CreatedAt",
+	colTitle:      "Title",
+	colMCC:        "MCC",
+	colAmount:     "Amount",
+	colAmountOrig: "AmountOrig",
+	colCurrency:   "Currency",
+	colExchange:   "Exchange",
+	colCommission: "Commission",
+	colCashback:   "Cashback",
+	colRest:       "Rest",
+}
+
+// MonobankProfile - monobank CSV statement, default profile
+var MonobankProfile = Profile{
+	Name:       "monobank",
+	CentsCoef:  CentsCoef,
+	RateCoef:   RateCoef,
+	DateFormat: DateFormat,
+	Headers: map[string][]string{
+		"CreatedAt":  {"Дата i час операції", "Дата і час операції"},
+		"Title":      {"Деталі операції"},
+		"MCC":        {"MCC"},
+		"Amount":     {"Сума в валюті картки"},
+		"AmountOrig": {"Сума в валюті операції"},
+		"Currency":   {"Валюта"},
+		"Exchange":   {"Курс"},
+		"Commission": {"Сума комісій"},
+		"Cashback":   {"Сума кешбеку"},
+		"Rest":       {"Залишок після операції"},
+	},
+}
+
+// Profiles - built-in profiles by name, other statement variants can be added here
+var Profiles = map[string]Profile{
+	MonobankProfile.Name: MonobankProfile,
+}
+
+// LookupProfile - built-in profile by name
+func LookupProfile(name string) (Profile, error) {
+	prof, ok := Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(Profiles))
+		for name := range Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("Unknown statement profile: %s, known profiles: %s", name, strings.Join(names, ", "))
+	}
+
+	return prof, nil
+}
+
+// headerName - the first header name of column for error messages
+func (prof Profile) headerName(col column) string {
+	if names := prof.Headers[columnFields[col]]; len(names) > 0 {
+		return names[0]
+	}
+
+	return columnFields[col]
+}
+
+// parseAmount - amount in statement units to kopecks
+func (prof Profile) parseAmount(s string) (int, error) {
+	return ParseAsInt(s, prof.CentsCoef)
+}
+
+// parseRate - exchange rate in statement units to rate * 100000, NULL if it's absent
+func (prof Profile) parseRate(s string) (sql.NullInt64, error) {
+	return ParseAsNullInt(s, prof.RateCoef)
+}
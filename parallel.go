@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// parsedBuffer - records and messages of one file buffered ahead of processing, so memory of parsing doesn't
+// depend on file sizes, a worker waits for processing of earlier files if the buffer is full
+const parsedBuffer = 1000
+
+// errParseCanceled - parsing is stopped, processing of records failed
+var errParseCanceled = errors.New("Parsing is canceled")
+
+// parsedItem - record or message of parsing (if log isn't nil), in order of reading
+type parsedItem struct {
+	rec monoparse.Record
+	log func()
+}
+
+// parsedFile - records of one file parsed by worker of parseFiles
+type parsedFile struct {
+	items   chan parsedItem // closed after reading of the file, then rowErrs and err are set
+	rowErrs []error         // records which can't be parsed, with -fail-fast=false
+	err     error           // error of reading or parsing the file
+}
+
+// parseFiles - parse files concurrently by up to opts.parallel goroutines, results are in order of files
+// and records of each file are received as they are read, so dedup and messages don't depend on number of workers,
+// files are taken by workers in order, so the earliest unfinished file is always read.
+// Closing of done stops workers, stdin is read by one of them as any other file
+func parseFiles(files []string, opts readOptions, done <-chan struct{}) []*parsedFile {
+	result := make([]*parsedFile, len(files))
+	for i := range result {
+		result[i] = &parsedFile{items: make(chan parsedItem, parsedBuffer)}
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < min(opts.parallel, len(files)); w++ {
+		go func() {
+			for i := range jobs {
+				res := result[i]
+				send := func(item parsedItem) error {
+					select {
+					case res.items <- item:
+						return nil
+					case <-done:
+						return errParseCanceled
+					}
+				}
+				res.rowErrs, res.err = readCSV(files[i], opts, func(rec monoparse.Record) error {
+					return send(parsedItem{rec: rec})
+				}, func(log func()) {
+					_ = send(parsedItem{log: log})
+				})
+				close(res.items)
+			}
+		}()
+	}
+
+	return result
+}
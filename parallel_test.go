@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+const testHeader = `"Дата i час операції","Деталі операції",MCC,"Сума в валюті картки (UAH)","Сума в валюті операції",Валюта,Курс,"Сума комісій (UAH)","Сума кешбеку (UAH)","Залишок після операції"`
+
+// writeStatements - CSV files of n records, each file overlaps with the previous one by half of records,
+// and has a duplicate within file and a broken row
+func writeStatements(t *testing.T, files, n int) []string {
+	t.Helper()
+
+	start := time.Date(2024, time.February, 1, 10, 0, 0, 0, time.UTC)
+	dir, names := t.TempDir(), []string{}
+	for f := 0; f < files; f++ {
+		lines := []string{testHeader}
+		for i := 0; i < n; i++ {
+			// records of overlapping halves are the same operations, duplicates across files
+			at := start.Add(time.Duration(f*n/2+i) * time.Minute)
+			lines = append(lines, fmt.Sprintf(`"%s","Shop %d",5411,-%d.00,-%d.00,UAH,—,—,—,1000.00`, at.Format(monoparse.DateFormat), (f*n/2+i)%7, 1+(f*n/2+i)%5, 1+(f*n/2+i)%5))
+			if i == n/3 {
+				lines = append(lines, lines[len(lines)-1], `"broken",Shop,5411,-1.00,-1.00,UAH,—,—,—,1000.00`)
+			}
+		}
+		name := filepath.Join(dir, fmt.Sprintf("statement_%d.csv", f))
+		if err := os.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// captureLogs - messages of logger while running fn
+func captureLogs(t *testing.T, fn func()) string {
+	t.Helper()
+
+	saved := *logger
+	defer func() { *logger = saved }()
+	buf := &bytes.Buffer{}
+	logger.out, logger.warnOut, logger.warnings = buf, buf, nil
+	fn()
+
+	return buf.String()
+}
+
+func TestReadFilesParallel(t *testing.T) {
+	// more records than parsedBuffer, so workers wait for processing of earlier files
+	files := writeStatements(t, 5, 3*parsedBuffer)
+
+	type result struct {
+		data       []monoparse.Record
+		duplicates map[string]duplicateCounts
+		rowErrs    []error
+		logs       string
+	}
+	read := func(parallel int) result {
+		res := result{}
+		res.logs = captureLogs(t, func() {
+			var err error
+			res.duplicates, res.rowErrs, err = readFiles(files, readOptions{parallel: parallel, onDuplicate: onDuplicateFirstWins}, func(rec monoparse.Record) error {
+				res.data = append(res.data, rec)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+		return res
+	}
+
+	want := read(1)
+	if len(want.data) == 0 || len(want.rowErrs) != len(files) || len(want.duplicates) != len(files) {
+		t.Fatalf("unexpected result of sequential reading: %d records, %d errors, duplicates %v", len(want.data), len(want.rowErrs), want.duplicates)
+	}
+	for _, parallel := range []int{2, 4, 8} {
+		if got := read(parallel); !reflect.DeepEqual(got, want) {
+			t.Errorf("-parallel=%d: result differs from -parallel=1", parallel)
+		}
+	}
+
+	// records are in order of files, the first of duplicates wins
+	for i := 1; i < len(want.data); i++ {
+		if prev, rec := want.data[i-1], want.data[i]; prev.SourceFile > rec.SourceFile {
+			t.Fatalf("record %d of %s is after record of %s", i, rec.SourceFile, prev.SourceFile)
+		}
+	}
+}
+
+func TestReadFilesParallelError(t *testing.T) {
+	files := writeStatements(t, 4, 3*parsedBuffer)
+
+	captureLogs(t, func() {
+		// duplicates across files stop reading, workers of other files are stopped too
+		_, _, err := readFiles(files, readOptions{parallel: 4, onDuplicate: onDuplicateError}, func(monoparse.Record) error { return nil })
+		if !errors.Is(err, errDuplicateRecord) {
+			t.Errorf("expected duplicate error, got %v", err)
+		}
+	})
+}
+
+func TestSortByTime(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, time.February, 1, 10, minute, 0, 0, time.UTC) }
+	data := []monoparse.Record{
+		{CreatedAt: at(5), Title: "a.csv 1", SourceFile: "a.csv"},
+		{CreatedAt: at(1), Title: "a.csv 2", SourceFile: "a.csv"},
+		{CreatedAt: at(3), Title: "b.csv 1", SourceFile: "b.csv"},
+		{CreatedAt: at(1), Title: "b.csv 2", SourceFile: "b.csv"},
+		{CreatedAt: at(5), Title: "c.csv 1", SourceFile: "c.csv"},
+	}
+	sortByTime(data)
+
+	titles := []string{}
+	for _, rec := range data {
+		titles = append(titles, rec.Title)
+	}
+	// records with the same time are in order of files
+	if want := []string{"a.csv 2", "b.csv 2", "b.csv 1", "a.csv 1", "c.csv 1"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("sorted records: %v, expected %v", titles, want)
+	}
+}
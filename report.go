@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/msoap/mono-import/monodb"
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// -report values
+const (
+	reportMonthly    = "monthly"
+	reportWeekly     = "weekly"
+	reportByCategory = "by-category"
+	reportByMCC      = "by-mcc"
+	reportImports    = "imports" // past import runs from monodb.MetaTable
+)
+
+// reportCurrencyUAH - the only -report-currency, exchange rates in statements are rates to UAH
+const reportCurrencyUAH = "UAH"
+
+// reportRow - aggregated amounts for one group of operations, in UAH
+type reportRow struct {
+	Group   string  `db:"grp"`
+	Inflow  float64 `db:"inflow"`
+	Outflow float64 `db:"outflow"`
+	Net     float64 `db:"net"`
+}
+
+// reportSQL - query of report, groups by period are sorted by time, other groups by outflow,
+// with currency amounts of foreign operations are converted by their exchange rates,
+// with MarkTransfers records of transfers between accounts are excluded
+func reportSQL(dl monodb.Dialect, schema monodb.Options, report, currency string) (string, error) {
+	group, order := "", "grp"
+	switch report {
+	case reportMonthly:
+		group = dl.MonthSQL()
+	case reportWeekly:
+		group = dl.WeekSQL()
+	case reportByCategory:
+		if schema.Minimal {
+			return "", fmt.Errorf("Report %s needs category column, it's not in minimal schema", report)
+		}
+		group, order = "category", "outflow DESC, grp"
+	case reportByMCC:
+		group, order = "mcc", "outflow DESC, grp"
+	default:
+		return "", fmt.Errorf("Unsupported report: %s", report)
+	}
+
+	amount, err := reportAmountSQL(schema, currency)
+	if err != nil {
+		return "", err
+	}
+
+	where := ""
+	if schema.MarkTransfers {
+		where = " WHERE transfer IS NULL OR transfer = FALSE"
+	}
+
+	return fmt.Sprintf(`
+	SELECT
+		grp,
+		SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END) AS inflow,
+		SUM(CASE WHEN amount < 0 THEN -amount ELSE 0 END) AS outflow,
+		SUM(amount) AS net
+	FROM (SELECT %s AS grp, %s AS amount FROM %s%s) t
+	GROUP BY grp
+	ORDER BY %s
+`, group, amount, schema.Table, where, order), nil
+}
+
+// reportAmountSQL - amount of operation in report: in card currency, or with -report-currency=UAH
+// amount in operation currency multiplied by its exchange rate (for foreign operations),
+// operations in UAH and without exchange rate pass through unchanged
+func reportAmountSQL(schema monodb.Options, currency string) (string, error) {
+	switch currency {
+	case "":
+		return "amount", nil
+	case reportCurrencyUAH:
+		if schema.Minimal {
+			return "", fmt.Errorf("Report currency needs currency columns, they are not in minimal schema")
+		}
+		converted := "amount_orig * exchange"
+		if schema.Kopecks {
+			converted = fmt.Sprintf("amount_orig * exchange / %d.0", monoparse.RateCoef)
+		}
+		return fmt.Sprintf("CASE WHEN currency NOT IN ('', '%s') AND exchange IS NOT NULL THEN %s ELSE amount END", reportCurrencyUAH, converted), nil
+	default:
+		return "", fmt.Errorf("Unsupported report currency: %s, only %s is supported", currency, reportCurrencyUAH)
+	}
+}
+
+// convertedAmount - amount of record in UAH for -report-currency, the same conversion as in reportAmountSQL
+func convertedAmount(rec monoparse.Record) int {
+	if rec.Currency == "" || rec.Currency == reportCurrencyUAH || !rec.Exchange.Valid {
+		return rec.Amount
+	}
+
+	return int(math.Round(float64(rec.AmountOrig) * float64(rec.Exchange.Int64) / monoparse.RateCoef))
+}
+
+// printReport - print aggregated amounts from existing DB, CSV files are not read
+func printReport(opts dbOptions, report, currency string) error {
+	db, err := openExistingDB(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return printReportDB(db, opts.schema, report, currency)
+}
+
+// printReportDB - print report from open DB, e.g. in-memory DB after import
+func printReportDB(db *sqlx.DB, schema monodb.Options, report, currency string) error {
+	if report == reportImports {
+		return printImportsReport(db)
+	}
+
+	dl, err := monodb.NewDialect(db.DriverName(), schema)
+	if err != nil {
+		return err
+	}
+
+	query, err := reportSQL(dl, schema, report, currency)
+	if err != nil {
+		return err
+	}
+
+	rows := []reportRow{}
+	if err := db.Select(&rows, query); err != nil {
+		return fmt.Errorf("Error querying report: %s", err)
+	}
+
+	header := "period"
+	switch report {
+	case reportByCategory:
+		header = "category"
+	case reportByMCC:
+		header = "mcc"
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tinflow\toutflow\tnet\t\n", header)
+	// amounts in DB are decimals or kopecks with -store-as=kopecks
+	coef := float64(monoparse.CentsCoef)
+	if schema.Kopecks {
+		coef = 1
+	}
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", row.Group, formatAmount(row.Inflow*coef), formatAmount(row.Outflow*coef), formatAmount(row.Net*coef))
+	}
+
+	return tw.Flush()
+}
+
+// printImportsReport - print past import runs to all tables of DB
+func printImportsReport(db *sqlx.DB) error {
+	// time is scanned as string, MySQL driver returns DATETIME as bytes without parseTime DSN parameter
+	rows := []struct {
+		ImportedAt string `db:"imported_at"`
+		Version    string `db:"version"`
+		TableName  string `db:"table_name"`
+		Files      string `db:"files"`
+		Parsed     int    `db:"parsed"`
+		Inserted   int    `db:"inserted"`
+	}{}
+	query := "SELECT imported_at, version, table_name, files, parsed, inserted FROM " + monodb.MetaTable + " ORDER BY imported_at"
+	if err := db.Select(&rows, query); err != nil {
+		return fmt.Errorf("Error querying import runs (table %s is created by import): %s", monodb.MetaTable, err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "imported at	version	table	parsed	inserted	files")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%s\n", row.ImportedAt, row.Version, row.TableName, row.Parsed, row.Inserted, row.Files)
+	}
+
+	return tw.Flush()
+}
+
+// formatAmount - format sum of kopecks from DB, sums of DECIMAL columns in SQLite are floats
+func formatAmount(kopecks float64) string {
+	return formatDecimal(int(math.Round(kopecks)), monoparse.CentsCoef)
+}
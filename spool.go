@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// spoolChunk - records of spool kept in memory, about 3MB, the rest is written to a temporary file by chunks
+const spoolChunk = 10_000
+
+// recordSpool - records of run after filtering and dedup, for exporting or importing to DBs after reading of all files.
+// Up to chunk records are kept in memory, other records are written to a temporary file (gob-encoded) by chunks,
+// so memory doesn't depend on number of records. With sorted, records are iterated by operation time as by sortByTime:
+// chunks are sorted before writing and merged on reading
+type recordSpool struct {
+	chunk   int
+	sorted  bool
+	n       int
+	mem     []monoparse.Record // the last chunk
+	file    *os.File           // created for the first written chunk
+	removed bool               // the file is removed already, it's readable while open
+	size    int64              // size of written chunks
+	runs    []spoolRun
+}
+
+// spoolRun - chunk of records written to the file, encoded one by one
+type spoolRun struct {
+	offset, size int64
+	n            int
+}
+
+func newRecordSpool(sorted bool) *recordSpool {
+	return &recordSpool{chunk: spoolChunk, sorted: sorted}
+}
+
+// Add - add record to spool, error of writing to the temporary file
+func (s *recordSpool) Add(rec monoparse.Record) error {
+	s.mem = append(s.mem, rec)
+	s.n++
+	if len(s.mem) < s.chunk {
+		return nil
+	}
+
+	return s.flush()
+}
+
+// Len - number of records
+func (s *recordSpool) Len() int {
+	return s.n
+}
+
+// flush - write records in memory to the file as a new run
+func (s *recordSpool) flush() error {
+	if s.file == nil {
+		f, err := os.CreateTemp("", "mono-import-*.spool")
+		if err != nil {
+			return fmt.Errorf("Error creating temporary file: %w", err)
+		}
+		// removed right away, so it's removed on any exit, Windows doesn't remove open files, it's removed by Close then
+		s.removed = os.Remove(f.Name()) == nil
+		s.file = f
+	}
+	if s.sorted {
+		sortByTime(s.mem)
+	}
+
+	w := bufio.NewWriter(s.file)
+	enc := gob.NewEncoder(w)
+	for i := range s.mem {
+		if err := enc.Encode(&s.mem[i]); err != nil {
+			return fmt.Errorf("Error writing temporary file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("Error writing temporary file: %w", err)
+	}
+	size, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("Error writing temporary file: %w", err)
+	}
+
+	s.runs = append(s.runs, spoolRun{offset: s.size, size: size - s.size, n: len(s.mem)})
+	s.size, s.mem = size, s.mem[:0]
+
+	return nil
+}
+
+// Each - call fn for each record, in order of adding or by operation time for sorted spool,
+// stops on error of fn, it can be called several times
+func (s *recordSpool) Each(fn func(monoparse.Record) error) error {
+	cursors := make([]*spoolCursor, 0, len(s.runs)+1)
+	for _, run := range s.runs {
+		r := bufio.NewReader(io.NewSectionReader(s.file, run.offset, run.size))
+		cursors = append(cursors, &spoolCursor{dec: gob.NewDecoder(r), left: run.n})
+	}
+	if s.sorted {
+		sortByTime(s.mem)
+	}
+	cursors = append(cursors, &spoolCursor{mem: s.mem, left: len(s.mem)})
+
+	if !s.sorted {
+		for _, c := range cursors {
+			for {
+				ok, err := c.next()
+				if err != nil {
+					return err
+				}
+				if !ok {
+					break
+				}
+				if err := fn(c.rec); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	// merge of sorted runs, records with the same time are taken from earlier runs first, so order is stable
+	h := cursorHeap{}
+	for i, c := range cursors {
+		c.run = i
+		ok, err := c.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, c)
+		}
+	}
+	heap.Init(&h)
+	for len(h) > 0 {
+		c := h[0]
+		if err := fn(c.rec); err != nil {
+			return err
+		}
+
+		ok, err := c.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+
+	return nil
+}
+
+// Close - close and remove the temporary file
+func (s *recordSpool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	if !s.removed {
+		if rmErr := os.Remove(s.file.Name()); err == nil {
+			err = rmErr
+		}
+	}
+	s.file = nil
+
+	return err
+}
+
+// spoolCursor - reader of records of one run or of records in memory (if dec is nil)
+type spoolCursor struct {
+	dec  *gob.Decoder
+	mem  []monoparse.Record
+	left int
+	run  int // index of run, for stable merge
+	rec  monoparse.Record
+}
+
+// next - read the next record to rec, false at the end of run
+func (c *spoolCursor) next() (bool, error) {
+	if c.left == 0 {
+		return false, nil
+	}
+	c.left--
+
+	if c.dec == nil {
+		c.rec, c.mem = c.mem[0], c.mem[1:]
+		return true, nil
+	}
+
+	// gob doesn't write zero values, so fields of the previous record are reset
+	c.rec = monoparse.Record{}
+	if err := c.dec.Decode(&c.rec); err != nil {
+		return false, fmt.Errorf("Error reading temporary file: %w", err)
+	}
+
+	return true, nil
+}
+
+// cursorHeap - cursors by time of current record and by run
+type cursorHeap []*spoolCursor
+
+func (h cursorHeap) Len() int { return len(h) }
+
+func (h cursorHeap) Less(i, j int) bool {
+	a, b := h[i].rec.CreatedAt, h[j].rec.CreatedAt
+	return a.Before(b) || a.Equal(b) && h[i].run < h[j].run
+}
+
+func (h cursorHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *cursorHeap) Push(x any) { *h = append(*h, x.(*spoolCursor)) }
+
+func (h *cursorHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// spoolTitles - titles of records of spool in order of Each
+func spoolTitles(t *testing.T, s *recordSpool) []string {
+	t.Helper()
+
+	titles := []string{}
+	if err := s.Each(func(rec monoparse.Record) error {
+		titles = append(titles, rec.Title)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return titles
+}
+
+func TestRecordSpool(t *testing.T) {
+	base := readTestFile(t, "testdata/statement.csv", readOptions{failFast: true})
+	data := []monoparse.Record{}
+	for f := 0; f < 10; f++ {
+		for _, rec := range base {
+			// records of files overlap in time, some records have the same time
+			rec.CreatedAt = rec.CreatedAt.Add(time.Duration(f%4) * time.Hour)
+			rec.SourceFile = fmt.Sprintf("%d.csv", f)
+			rec.Title = fmt.Sprintf("%s %s", rec.SourceFile, rec.Title)
+			data = append(data, rec)
+		}
+	}
+
+	for _, sorted := range []bool{false, true} {
+		// chunk of 7 records: several runs in the file and records in memory
+		s := &recordSpool{chunk: 7, sorted: sorted}
+		for _, rec := range data {
+			if err := s.Add(rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if s.Len() != len(data) || len(s.runs) != len(data)/7 {
+			t.Fatalf("sorted=%v: %d records in %d runs, expected %d", sorted, s.Len(), len(s.runs), len(data))
+		}
+
+		want := append([]monoparse.Record{}, data...)
+		if sorted {
+			sortByTime(want)
+		}
+		titles := []string{}
+		for _, rec := range want {
+			titles = append(titles, rec.Title)
+		}
+		if got := spoolTitles(t, s); !reflect.DeepEqual(got, titles) {
+			t.Errorf("sorted=%v: order of records:\n%v\nexpected:\n%v", sorted, got, titles)
+		}
+		// it can be iterated again, e.g. for summary after export
+		if got := spoolTitles(t, s); !reflect.DeepEqual(got, titles) {
+			t.Errorf("sorted=%v: order of records of the second iteration differs", sorted)
+		}
+
+		// values of records are restored from the file, zero values too
+		i := 0
+		if err := s.Each(func(rec monoparse.Record) error {
+			w := want[i]
+			i++
+			if !rec.CreatedAt.Equal(w.CreatedAt) {
+				t.Errorf("record %d: time %s, expected %s", i, rec.CreatedAt, w.CreatedAt)
+			}
+			rec.CreatedAt, w.CreatedAt = time.Time{}, time.Time{}
+			if !reflect.DeepEqual(rec, w) {
+				t.Errorf("record %d:\n%+v\nexpected:\n%+v", i, rec, w)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		name := s.file.Name()
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("temporary file isn't removed: %v", err)
+		}
+	}
+}
+
+func TestRecordSpoolEachError(t *testing.T) {
+	s := &recordSpool{chunk: 2}
+	defer s.Close()
+	for i := 0; i < 5; i++ {
+		if err := s.Add(monoparse.Record{Title: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errStop, n := errors.New("stop"), 0
+	err := s.Each(func(monoparse.Record) error {
+		if n++; n == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || n != 3 {
+		t.Errorf("expected error of fn after 3 records, got %v after %d", err, n)
+	}
+}
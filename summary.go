@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// fileSummary - parsed/inserted/skipped counts for one source file
+type fileSummary struct {
+	File     string `json:"file"`
+	Parsed   int    `json:"parsed"`
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped"`
+	duplicateCounts
+}
+
+// duplicateCounts - duplicates within run skipped in a file: of records of the same file (suspicious,
+// e.g. broken export) and of records of previous files (expected for overlapping statements)
+type duplicateCounts struct {
+	InFile      int `json:"duplicates_in_file"`
+	AcrossFiles int `json:"duplicates_across_files"`
+}
+
+// fileSummaries - counts per source file in order of files, records are saved to dbCount DBs
+func fileSummaries(records *recordSpool, duplicates map[string]duplicateCounts, inserted map[string]int, dbCount int) ([]fileSummary, error) {
+	files, parsed := []string{}, map[string]int{}
+	if err := records.Each(func(rec monoparse.Record) error {
+		if _, ok := parsed[rec.SourceFile]; !ok {
+			files = append(files, rec.SourceFile)
+		}
+		parsed[rec.SourceFile]++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	// files with only duplicates of previous files
+	rest := []string{}
+	for filename := range duplicates {
+		if _, ok := parsed[filename]; !ok {
+			rest = append(rest, filename)
+		}
+	}
+	sort.Strings(rest)
+	files = append(files, rest...)
+
+	result := make([]fileSummary, 0, len(files))
+	for _, filename := range files {
+		result = append(result, fileSummary{
+			File:     displayName(filename),
+			Parsed:   parsed[filename],
+			Inserted: inserted[filename],
+			Skipped:  parsed[filename]*dbCount - inserted[filename],
+
+			duplicateCounts: duplicates[filename],
+		})
+	}
+
+	return result, nil
+}
+
+// printFileSummary - table with parsed/inserted/skipped counts per source file
+func printFileSummary(records *recordSpool, inserted map[string]int) {
+	if logger.level < levelNormal {
+		return
+	}
+
+	summaries, err := fileSummaries(records, nil, inserted, 1)
+	if err != nil {
+		logger.Warnf("Error printing summary: %s", err)
+		return
+	}
+	if logger.slog != nil {
+		for _, fs := range summaries {
+			logger.With("file", fs.File, "parsed", fs.Parsed, "inserted", fs.Inserted, "skipped", fs.Skipped).
+				Infof("Imported %d (from %d) records from %s", fs.Inserted, fs.Parsed, fs.File)
+		}
+		return
+	}
+
+	tw := tabwriter.NewWriter(logger.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tparsed\tinserted\tskipped")
+	for _, fs := range summaries {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", fs.File, fs.Parsed, fs.Inserted, fs.Skipped)
+	}
+	if err := tw.Flush(); err != nil {
+		logger.Warnf("Error printing summary: %s", err)
+	}
+}
+
+// importSummary - result of import for -summary-format=json
+type importSummary struct {
+	Files       []fileSummary `json:"files"` // inserted and skipped are summed over DBs
+	DBs         []dbSummary   `json:"dbs"`
+	Parsed      int           `json:"parsed"`
+	Inserted    int           `json:"inserted"`
+	Skipped     int           `json:"skipped"`
+	DurationSec float64       `json:"duration_sec"`
+	Warnings    []string      `json:"warnings"`
+	DryRun      bool          `json:"dry_run"`
+	duplicateCounts
+}
+
+// dbSummary - result of import to one DB
+type dbSummary struct {
+	DB       string `json:"db"`
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped"`
+	Error    string `json:"error,omitempty"`
+}
+
+// printJSONSummary - print import summary to stdout as JSON, status messages are in stderr in this mode
+func printJSONSummary(records *recordSpool, duplicates map[string]duplicateCounts, results []targetResult, duration time.Duration, dryRun bool) error {
+	inserted, dbs, saved := map[string]int{}, []dbSummary{}, 0
+	for _, res := range results {
+		db := dbSummary{DB: redactDSN(res.opts.dsn)}
+		for filename, cnt := range res.inserted {
+			inserted[filename] += cnt
+			db.Inserted += cnt
+		}
+		if res.err != nil {
+			db.Error = res.err.Error()
+		} else {
+			db.Skipped = records.Len() - db.Inserted
+			saved++
+		}
+		dbs = append(dbs, db)
+	}
+
+	// records of dry run are not saved and not checked against DB, so they aren't skipped
+	dbCount := max(saved, 1)
+	if dryRun {
+		dbCount = 0
+	}
+	files, err := fileSummaries(records, duplicates, inserted, dbCount)
+	if err != nil {
+		return err
+	}
+	summary := importSummary{
+		Files:       files,
+		DBs:         dbs,
+		Parsed:      records.Len(),
+		DurationSec: duration.Seconds(),
+		Warnings:    logger.warnings,
+		DryRun:      dryRun,
+	}
+	for _, db := range summary.DBs {
+		summary.Inserted += db.Inserted
+		summary.Skipped += db.Skipped
+	}
+	for _, cnt := range duplicates {
+		summary.InFile += cnt.InFile
+		summary.AcrossFiles += cnt.AcrossFiles
+	}
+	if summary.Warnings == nil {
+		summary.Warnings = []string{}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(summary)
+}
+
+// currencyTotals - aggregated amounts for one operation currency
+type currencyTotals struct {
+	inflow, outflow      int // in operation currency
+	commission, cashback int // in card currency
+}
+
+// printCurrencySummary - totals per operation currency, printed for -summary flag,
+// with reportCurrency all amounts are converted to it and summed in one row
+func printCurrencySummary(records *recordSpool, reportCurrency string) {
+	totals := map[string]*currencyTotals{}
+	if err := records.Each(func(rec monoparse.Record) error {
+		currency, amount := rec.Currency, rec.AmountOrig
+		if reportCurrency != "" {
+			currency, amount = reportCurrency, convertedAmount(rec)
+		}
+		t, ok := totals[currency]
+		if !ok {
+			t = &currencyTotals{}
+			totals[currency] = t
+		}
+
+		if amount >= 0 {
+			t.inflow += amount
+		} else {
+			t.outflow += amount
+		}
+		t.commission += rec.Commission
+		t.cashback += rec.Cashback
+		return nil
+	}); err != nil {
+		logger.Warnf("Error printing summary: %s", err)
+		return
+	}
+
+	currencies := make([]string, 0, len(totals))
+	for currency := range totals {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	tw := tabwriter.NewWriter(logger.out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "currency\tinflow\toutflow\tcommission (card)\tcashback (card)\tnet\t")
+	for _, currency := range currencies {
+		t := totals[currency]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			currency,
+			formatDecimal(t.inflow, monoparse.CentsCoef),
+			formatDecimal(t.outflow, monoparse.CentsCoef),
+			formatDecimal(t.commission, monoparse.CentsCoef),
+			formatDecimal(t.cashback, monoparse.CentsCoef),
+			formatDecimal(t.inflow+t.outflow, monoparse.CentsCoef),
+		)
+	}
+	if err := tw.Flush(); err != nil {
+		logger.Warnf("Error printing summary: %s", err)
+	}
+}
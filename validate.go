@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/msoap/mono-import/monoparse"
+)
+
+// -validate checks, currency and mcc are checks of parser
+const (
+	validateBalance    = "balance"    // balance after each operation, see checkBalance
+	validateDuplicates = "duplicates" // duplicates within run by dedup key
+	validateAccounts   = "accounts"   // files from different accounts, see accountCheck
+)
+
+var validateChecks = []string{validateBalance, string(monoparse.CheckCurrency), string(monoparse.CheckMCC), validateDuplicates, validateAccounts}
+
+// validation - anomalies found by enabled checks of -validate, records which can't be parsed are always anomalies
+type validation struct {
+	enabled map[string]bool
+	counts  map[string]int
+}
+
+// newValidation - checks by comma-separated names or "all"
+func newValidation(names string) (*validation, error) {
+	v := &validation{enabled: map[string]bool{}, counts: map[string]int{}}
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); {
+		case name == "all":
+			for _, check := range validateChecks {
+				v.enabled[check] = true
+			}
+		case slices.Contains(validateChecks, name):
+			v.enabled[name] = true
+		default:
+			return nil, fmt.Errorf("Unsupported -validate check: %q, checks: all, %s", name, strings.Join(validateChecks, ", "))
+		}
+	}
+
+	return v, nil
+}
+
+// add - n anomalies found by check, no-op if validation is off or the check is disabled
+func (v *validation) add(check string, n int) {
+	if v != nil && v.enabled[check] {
+		v.counts[check] += n
+	}
+}
+
+// skipChecks - disabled checks of parser
+func (v *validation) skipChecks() map[monoparse.Check]bool {
+	skip := map[monoparse.Check]bool{}
+	for _, check := range []monoparse.Check{monoparse.CheckCurrency, monoparse.CheckMCC} {
+		skip[check] = !v.enabled[string(check)]
+	}
+
+	return skip
+}
+
+// report - print number of anomalies by check, returns total number of them
+func (v *validation) report(w io.Writer, records, parseErrors int) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "check\tanomalies\n")
+	fmt.Fprintf(tw, "parse\t%d\n", parseErrors)
+	total := parseErrors
+	for _, check := range validateChecks {
+		if !v.enabled[check] {
+			fmt.Fprintf(tw, "%s\tskipped\n", check)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", check, v.counts[check])
+		total += v.counts[check]
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "Validated %d records, found %d anomalies\n", records, total)
+
+	return total
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/msoap/mono-import/monoparse"
+	"github.com/xuri/excelize/v2"
+)
+
+// readXLSX - read statement from the first sheet of Excel file,
+// rows before header (title banner, merged cells) are skipped, all rows are records with -no-header
+func readXLSX(r io.Reader, parser monoparse.Parser, fn func(monoparse.Record) error) error {
+	xf, err := excelize.OpenReader(r)
+	if err != nil {
+		return fmt.Errorf("Error opening XLSX file: %w", err)
+	}
+	defer xf.Close()
+
+	sheets := xf.GetSheetList()
+	if len(sheets) == 0 {
+		return nil
+	}
+
+	// cells are formatted by their number formats, as displayed in Excel
+	rows, err := xf.GetRows(sheets[0])
+	if err != nil {
+		return fmt.Errorf("Error reading sheet %s: %w", sheets[0], err)
+	}
+
+	// line of the first row in sheet for errors
+	skipped := min(parser.SkipLines, len(rows))
+	rows = rows[skipped:]
+	if parser.NoHeader && len(rows) == 0 {
+		return nil
+	}
+	if !parser.NoHeader {
+		header := -1
+		for i, row := range rows {
+			if _, err := parser.ParseHeader(row); err == nil {
+				header = i
+				break
+			}
+		}
+		if header == -1 {
+			return fmt.Errorf("Error parsing XLSX: header not found on sheet %s", sheets[0])
+		}
+		rows, skipped = rows[header:], skipped+header
+	}
+
+	// trailing empty cells are not returned, so rows are padded to header length
+	width := len(rows[0])
+	if parser.NoHeader {
+		width = len(monoparse.DefaultColumns)
+	}
+	for i, row := range rows {
+		for len(row) < width {
+			row = append(row, "")
+		}
+		rows[i] = row
+	}
+
+	data, err := parser.ReadRowsFrom(rows, skipped+1)
+	if err != nil {
+		return err
+	}
+	for _, rec := range data {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}